@@ -0,0 +1,26 @@
+#!/bin/bash
+
+# Check Domain Layer Purity
+# Runs `goarchtest-server check-domain-purity` for the PostToolUse hook. The
+# plugin ships the server's sources only, so the binary is built on first use
+# and rebuilt when the sources are newer than it. The hook payload on stdin is
+# passed through unchanged.
+
+set -e
+
+SERVER_DIR="$(cd "$(dirname "$0")/../servers/goarchtest-server" && pwd)"
+BINARY="$SERVER_DIR/goarchtest-server"
+
+if ! command -v go >/dev/null 2>&1; then
+    echo "⚠️  Go is not installed, skipping the domain purity check" >&2
+    exit 0
+fi
+
+if [[ ! -x "$BINARY" ]] || [[ -n "$(find "$SERVER_DIR" -maxdepth 1 \( -name '*.go' -o -name 'go.mod' -o -name 'go.sum' \) -newer "$BINARY" -print -quit)" ]]; then
+    if ! (cd "$SERVER_DIR" && go build -o "$BINARY" .) >&2; then
+        echo "❌ Could not build goarchtest-server in $SERVER_DIR" >&2
+        exit 1
+    fi
+fi
+
+exec "$BINARY" check-domain-purity "$@"
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// architectureMap is the discovered structure of a module: its bounded
+// contexts, their layers and packages, and the internal packages that fit
+// none of the configured layers.
+type architectureMap struct {
+	Module       string        `json:"module"`
+	Rules        string        `json:"rules"`
+	Domains      []domainInfo  `json:"domains"`
+	Unclassified []packageInfo `json:"unclassified"`
+	ParseErrors  []string      `json:"parseErrors,omitempty"`
+}
+
+type domainInfo struct {
+	Name   string      `json:"name"`
+	Files  int         `json:"files"`
+	Layers []layerInfo `json:"layers"`
+}
+
+type layerInfo struct {
+	Name     string        `json:"name"`
+	Files    int           `json:"files"`
+	Packages []packageInfo `json:"packages"`
+}
+
+type packageInfo struct {
+	ImportPath string `json:"importPath"`
+	Path       string `json:"path"`
+	Files      int    `json:"files"`
+}
+
+// discoverArchitecture classifies every package of graph with rules. Domains
+// are only reported when at least one of their packages belongs to a layer;
+// layers appear in the order the rules declare them.
+func discoverArchitecture(graph *importGraph, rules *archRules) *architectureMap {
+	arch := &architectureMap{
+		Module:       graph.modulePath,
+		Rules:        rules.describe(),
+		Domains:      []domainInfo{},
+		Unclassified: []packageInfo{},
+		ParseErrors:  graph.parseErrors(),
+	}
+
+	byDomain := make(map[string]map[string][]packageInfo)
+	for _, pkg := range graph.sortedPackages() {
+		info := packageInfo{ImportPath: pkg.importPath, Path: pkg.relPath, Files: len(pkg.files)}
+		domain, layer := rules.classify(pkg.relPath)
+		if layer == "" {
+			if inTree("internal", pkg.relPath) {
+				arch.Unclassified = append(arch.Unclassified, info)
+			}
+			continue
+		}
+		if byDomain[domain] == nil {
+			byDomain[domain] = make(map[string][]packageInfo)
+		}
+		byDomain[domain][layer] = append(byDomain[domain][layer], info)
+	}
+
+	names := make([]string, 0, len(byDomain))
+	for name := range byDomain {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		d := domainInfo{Name: name, Layers: []layerInfo{}}
+		for _, layer := range rules.layerNames() {
+			pkgs, ok := byDomain[name][layer]
+			if !ok {
+				continue
+			}
+			l := layerInfo{Name: layer, Packages: pkgs}
+			for _, p := range pkgs {
+				l.Files += p.Files
+			}
+			d.Files += l.Files
+			d.Layers = append(d.Layers, l)
+		}
+		arch.Domains = append(arch.Domains, d)
+	}
+
+	return arch
+}
+
+// inTree reports whether rel is dir or lies below it.
+func inTree(dir, rel string) bool {
+	return rel == dir || strings.HasPrefix(rel, dir+"/")
+}
+
+func (a *architectureMap) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Module %s (rules: %s)\n", a.Module, a.Rules)
+
+	if len(a.Domains) == 0 {
+		b.WriteString("\nNo packages match the configured layers.\n")
+	}
+	for _, d := range a.Domains {
+		name := d.Name
+		if name == "" {
+			name = "(no domain)"
+		}
+		fmt.Fprintf(&b, "\n%s (%d files)\n", name, d.Files)
+		for _, l := range d.Layers {
+			fmt.Fprintf(&b, "  %s (%d files)\n", l.Name, l.Files)
+			for _, p := range l.Packages {
+				fmt.Fprintf(&b, "    - %s (%d files)\n", p.Path, p.Files)
+			}
+		}
+	}
+
+	if len(a.Unclassified) > 0 {
+		b.WriteString("\n⚠️ Packages under internal/ that fit no layer:\n")
+		for _, p := range a.Unclassified {
+			fmt.Fprintf(&b, "  - %s (%d files)\n", p.Path, p.Files)
+		}
+	}
+
+	if len(a.ParseErrors) > 0 {
+		b.WriteString("\n⚠️ Files with syntax errors, analyzed as far as they parse:\n")
+		for _, e := range a.ParseErrors {
+			fmt.Fprintf(&b, "  - %s\n", e)
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// baselineFileName is the committed record of accepted violations.
+const baselineFileName = ".goarch-baseline.json"
+
+// baseline lists the violations a project has accepted. Checks run against
+// it only report violations that are not recorded, so legacy code can adopt
+// the rules and shrink the file over time.
+type baseline struct {
+	Version    int         `json:"version"`
+	Generated  time.Time   `json:"generated"`
+	Violations []violation `json:"violations"`
+}
+
+// baselineResult describes how a baseline was applied to a report.
+type baselineResult struct {
+	File       string      `json:"file"`
+	Suppressed int         `json:"suppressed"`
+	Fixed      []violation `json:"fixed"`
+}
+
+// fingerprint identifies a violation independently of its line, so that
+// edits elsewhere in a file do not turn baseline entries into new findings.
+func (v violation) fingerprint() string {
+	return v.Rule + "\x00" + v.Package + "\x00" + v.Import + "\x00" + v.File + "\x00" + v.Message
+}
+
+func (s *GoArchTestServer) baselinePath() string {
+	return filepath.Join(s.projectRoot, baselineFileName)
+}
+
+// loadBaseline reads the project's baseline. It returns nil without error
+// when the project has none.
+func (s *GoArchTestServer) loadBaseline() (*baseline, error) {
+	return readBaseline(s.baselinePath())
+}
+
+func readBaseline(p string) (*baseline, error) {
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+
+	var b baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", baselineFileName, err)
+	}
+	return &b, nil
+}
+
+func (s *GoArchTestServer) writeBaseline(violations []violation) error {
+	b := baseline{Version: 1, Generated: time.Now().UTC().Truncate(time.Second), Violations: violations}
+	if b.Violations == nil {
+		b.Violations = []violation{}
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.baselinePath(), append(data, '\n'), 0o644)
+}
+
+// recordBaseline writes every current violation that no archtest:ignore
+// directive suppresses to the baseline, or with pruneOnly only drops the
+// entries that no longer occur. It returns how many entries the file holds
+// and how many were pruned.
+func (s *GoArchTestServer) recordBaseline(pruneOnly bool) (recorded, removed int, err error) {
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return 0, 0, fmt.Errorf("loading packages: %w", err)
+	}
+
+	_, current := runAllRules(graph, rules)
+	current = unsuppressed(graph, current)
+	violations := current
+
+	if pruneOnly {
+		existing, err := s.loadBaseline()
+		if err != nil {
+			return 0, 0, err
+		}
+		if existing == nil {
+			return 0, 0, fmt.Errorf("no %s to prune; create the baseline first", baselineFileName)
+		}
+		// Keep the still-present entries of the old baseline only, so new
+		// violations can never sneak into the file.
+		fixed := existing.fixed(current)
+		removed = len(fixed)
+		violations, _ = (&baseline{Violations: fixed}).subtract(existing.Violations)
+	}
+
+	violations = newCheckReport("create_baseline", violations).Violations
+	if err := s.writeBaseline(violations); err != nil {
+		return 0, 0, fmt.Errorf("writing baseline: %w", err)
+	}
+	return len(violations), removed, nil
+}
+
+func baselineMessage(recorded, removed int, pruneOnly bool) string {
+	if pruneOnly {
+		return fmt.Sprintf("Removed %d fixed violation(s) from %s, %d remain", removed, baselineFileName, recorded)
+	}
+	return fmt.Sprintf("Recorded %d violation(s) in %s", recorded, baselineFileName)
+}
+
+// subtract removes the baseline entries from violations. Identical
+// fingerprints are matched one to one, so a second copy of an accepted
+// violation is still reported.
+func (b *baseline) subtract(violations []violation) (remaining []violation, suppressed int) {
+	known := make(map[string]int)
+	for _, v := range b.Violations {
+		known[v.fingerprint()]++
+	}
+	remaining = []violation{}
+	for _, v := range violations {
+		if fp := v.fingerprint(); known[fp] > 0 {
+			known[fp]--
+			suppressed++
+			continue
+		}
+		remaining = append(remaining, v)
+	}
+	return remaining, suppressed
+}
+
+// fixed returns the baseline entries that no longer occur in current, which
+// must be the result of a full sweep.
+func (b *baseline) fixed(current []violation) []violation {
+	seen := make(map[string]int)
+	for _, v := range current {
+		seen[v.fingerprint()]++
+	}
+	fixed := []violation{}
+	for _, v := range b.Violations {
+		if fp := v.fingerprint(); seen[fp] > 0 {
+			seen[fp]--
+			continue
+		}
+		fixed = append(fixed, v)
+	}
+	return fixed
+}
+
+// filterReport removes the violations that archtest:ignore directives
+// suppress from report, then filters it through the project's baseline
+// unless the request sets baseline=false. Files with syntax errors are noted,
+// as their violations may be incomplete.
+func (s *GoArchTestServer) filterReport(request mcp.CallToolRequest, graph *importGraph, rules *archRules, report *checkReport) error {
+	ignoreSuppressed(graph, report)
+	if request.GetBool("baseline", true) {
+		if err := s.subtractBaseline(graph, rules, report); err != nil {
+			return err
+		}
+	}
+	report.ParseErrors = graph.parseErrors()
+	return nil
+}
+
+// subtractBaseline removes the baseline's known violations from report when
+// the project has a baseline. Fixed entries are found with a full sweep so
+// that entries outside the check's scope are not mistaken for fixed ones, and
+// violations suppressed inline do not keep their entries alive.
+func (s *GoArchTestServer) subtractBaseline(graph *importGraph, rules *archRules, report *checkReport) error {
+	b, err := s.loadBaseline()
+	if err != nil || b == nil {
+		return err
+	}
+
+	remaining, suppressed := b.subtract(report.Violations)
+	_, all := runAllRules(graph, rules)
+	ignored := report.Ignored
+	*report = newCheckReport(report.Check, remaining)
+	report.Ignored = ignored
+	report.Baseline = &baselineResult{
+		File:       baselineFileName,
+		Suppressed: suppressed,
+		Fixed:      b.fixed(unsuppressed(graph, all)),
+	}
+	return nil
+}
+
+// filterNote summarizes the inline suppressions, the applied baseline and
+// the files with syntax errors for text output.
+func (r checkReport) filterNote() string {
+	var filters []string
+	if r.Ignored > 0 {
+		filters = append(filters, fmt.Sprintf("%d violation(s) suppressed by archtest:ignore directives (audit them with list_suppressions)", r.Ignored))
+	}
+	if r.Baseline != nil {
+		line := fmt.Sprintf("Baseline %s: %d known violation(s) suppressed", r.Baseline.File, r.Baseline.Suppressed)
+		if n := len(r.Baseline.Fixed); n > 0 {
+			line += fmt.Sprintf(", %d fixed (remove them with create_baseline pruneOnly=true or the baseline -prune command)", n)
+		}
+		filters = append(filters, line)
+	}
+
+	note := ""
+	if len(filters) > 0 {
+		note = "\n\n" + strings.Join(filters, "\n")
+	}
+	if len(r.ParseErrors) > 0 {
+		note += fmt.Sprintf("\n\n⚠️ %d file(s) have syntax errors and were analyzed as far as they parse:\n  - %s",
+			len(r.ParseErrors), strings.Join(r.ParseErrors, "\n  - "))
+	}
+	return note
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBaselineSubtractAndFixed(t *testing.T) {
+	a := violation{Rule: ruleLayerDeps, Package: "ex/a", Import: "ex/b", File: "a/a.go", Line: 3, Message: "a"}
+	b := violation{Rule: ruleLayerDeps, Package: "ex/b", Import: "ex/c", File: "b/b.go", Line: 4, Message: "b"}
+	movedA := a
+	movedA.Line = 30
+
+	tests := []struct {
+		name       string
+		baseline   []violation
+		current    []violation
+		remaining  []violation
+		suppressed int
+		fixed      []violation
+	}{
+		{name: "no baseline", current: []violation{a, b}, remaining: []violation{a, b}},
+		{name: "accepted violation", baseline: []violation{a}, current: []violation{a, b}, remaining: []violation{b}, suppressed: 1},
+		{name: "moved to another line", baseline: []violation{a}, current: []violation{movedA}, suppressed: 1},
+		{name: "a second copy is new", baseline: []violation{a}, current: []violation{a, a}, remaining: []violation{a}, suppressed: 1},
+		{name: "fixed violation", baseline: []violation{a, b}, current: []violation{b}, suppressed: 1, fixed: []violation{a}},
+		{name: "one of two copies fixed", baseline: []violation{a, a}, current: []violation{a}, suppressed: 1, fixed: []violation{a}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bl := &baseline{Violations: tt.baseline}
+			remaining, suppressed := bl.subtract(tt.current)
+			if !slices.Equal(remaining, append([]violation{}, tt.remaining...)) || suppressed != tt.suppressed {
+				t.Errorf("subtract = %+v, %d suppressed, want %+v, %d", remaining, suppressed, tt.remaining, tt.suppressed)
+			}
+			if fixed := bl.fixed(tt.current); !slices.Equal(fixed, append([]violation{}, tt.fixed...)) {
+				t.Errorf("fixed = %+v, want %+v", fixed, tt.fixed)
+			}
+		})
+	}
+}
+
+func TestBaselineRatchet(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"internal/order/domain/order.go": `package domain
+
+import _ "example.com/shop/internal/order/infrastructure"
+`,
+		"internal/order/domain/item.go": `package domain
+
+import _ "example.com/shop/internal/order/application"
+`,
+		"internal/order/application/service.go": "package application\n",
+		"internal/order/infrastructure/repo.go": "package infrastructure\n",
+	})
+	s := NewGoArchTestServer(root)
+
+	recorded, removed, err := s.recordBaseline(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded != 2 || removed != 0 {
+		t.Fatalf("recorded %d and removed %d violations, want 2 and 0", recorded, removed)
+	}
+
+	check := func() checkReport {
+		t.Helper()
+		graph, rules, err := s.analyze()
+		if err != nil {
+			t.Fatal(err)
+		}
+		report := newCheckReport("check_layer_dependencies", layerViolations(graph, rules, "domain", ""))
+		if err := s.subtractBaseline(graph, rules, &report); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	// A new violation is reported, the accepted ones are not.
+	edit(t, root, "internal/order/domain/item.go", `package domain
+
+import (
+	_ "example.com/shop/internal/order/application"
+	_ "example.com/shop/internal/order/application/dto"
+)
+`)
+	writeFiles(t, root, map[string]string{"internal/order/application/dto/dto.go": "package dto\n"})
+	report := check()
+	if len(report.Violations) != 1 || report.Violations[0].Import != "example.com/shop/internal/order/application/dto" {
+		t.Errorf("violations = %+v, want only the new import", report.Violations)
+	}
+	if report.Baseline == nil || report.Baseline.Suppressed != 2 || len(report.Baseline.Fixed) != 0 {
+		t.Errorf("baseline result = %+v, want 2 suppressed and none fixed", report.Baseline)
+	}
+
+	// Fixing an accepted violation flags its entry, and pruning drops it
+	// without recording the new violation.
+	edit(t, root, "internal/order/domain/order.go", "package domain\n")
+	report = check()
+	if report.Baseline == nil || report.Baseline.Suppressed != 1 || len(report.Baseline.Fixed) != 1 ||
+		report.Baseline.Fixed[0].Import != "example.com/shop/internal/order/infrastructure" {
+		t.Errorf("baseline result = %+v, want the infrastructure import fixed", report.Baseline)
+	}
+
+	recorded, removed, err = s.recordBaseline(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded != 1 || removed != 1 {
+		t.Errorf("prune kept %d and removed %d violations, want 1 and 1", recorded, removed)
+	}
+	b, err := s.loadBaseline()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Violations) != 1 || b.Violations[0].Import != "example.com/shop/internal/order/application" {
+		t.Errorf("baseline = %+v, want only the accepted application import", b.Violations)
+	}
+}
+
+func TestRunAllRulesIsKeptWithTheGraph(t *testing.T) {
+	graph := loadModule(t, map[string]string{
+		"internal/order/domain/order.go":        "package domain\n\nimport _ \"example.com/shop/internal/order/infrastructure\"\n",
+		"internal/order/infrastructure/repo.go": "package infrastructure\n",
+	})
+	rules := defaultRules()
+
+	domains, violations := runAllRules(graph, rules)
+	if !slices.Equal(domains, []string{"order"}) || len(violations) != 1 {
+		t.Fatalf("sweep = %q, %+v, want the order domain and one violation", domains, violations)
+	}
+	violations[0].Message = "changed by the caller"
+
+	_, again := runAllRules(graph, rules)
+	if len(again) != 1 || again[0].Message == "changed by the caller" {
+		t.Errorf("callers share the kept sweep: %+v", again)
+	}
+	if graph.sweptRules != rules {
+		t.Error("the sweep was not kept with the graph")
+	}
+
+	other := defaultRules()
+	other.Layers[0].DependsOn = []string{"infrastructure"}
+	if _, violations := runAllRules(graph, other); len(violations) != 0 {
+		t.Errorf("other rules reused the kept sweep: %+v", violations)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// graphCache keeps the import graph of the project between tool calls. Each
+// load stats the module's files and re-parses only the packages whose files
+// were added, removed or modified since the previous load. The new graph
+// reuses the type-checked packages of the old one that are not affected.
+type graphCache struct {
+	root string
+
+	mu     sync.Mutex
+	graph  *importGraph
+	goMod  fileStamp
+	stamps map[string][]fileStamp
+}
+
+func newGraphCache(root string) *graphCache {
+	return &graphCache{root: root}
+}
+
+// load returns an up-to-date graph and whether it replaced a graph returned
+// by a previous call. Graphs are never mutated once returned, so callers may keep
+// using an older one while another call refreshes the cache.
+func (c *graphCache) load() (*importGraph, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	goModPath := filepath.Join(c.root, "go.mod")
+	info, err := os.Stat(goModPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading go.mod: %w", err)
+	}
+	goMod := fileStamp{name: "go.mod", size: info.Size(), modTime: info.ModTime()}
+
+	dirs, err := scanModule(c.root)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// A new module path changes every import path, so start over.
+	if c.graph == nil || goMod != c.goMod {
+		modulePath, err := readModulePath(goModPath)
+		if err != nil {
+			return nil, false, err
+		}
+		graph, err := parseModule(c.root, modulePath, dirs)
+		if err != nil {
+			return nil, false, err
+		}
+		refreshed := c.graph != nil
+		c.graph, c.goMod, c.stamps = graph, goMod, dirs
+		return graph, refreshed, nil
+	}
+
+	var unchanged []*packageNode
+	for relDir, files := range dirs {
+		// Unchanged directories without a package only hold files that
+		// build constraints exclude.
+		if old, ok := c.graph.packages[c.graph.importPathOf(relDir)]; ok && slices.Equal(files, c.stamps[relDir]) {
+			unchanged = append(unchanged, old)
+		}
+	}
+	next := newImportGraph(c.root, c.graph.modulePath, retainFiles(c.graph.fset, unchanged))
+	for _, pkg := range unchanged {
+		next.packages[pkg.importPath] = pkg
+	}
+	reparsed := 0
+	for relDir, files := range dirs {
+		if slices.Equal(files, c.stamps[relDir]) {
+			continue
+		}
+		pkg, err := next.parsePackage(relDir, files)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(pkg.files) > 0 {
+			next.packages[pkg.importPath] = pkg
+		}
+		reparsed++
+	}
+
+	changed := reparsed > 0 || len(next.packages) != len(c.graph.packages)
+	if !changed {
+		return c.graph, false, nil
+	}
+	next.previous = c.graph.typedPackagesIfChecked()
+	c.graph, c.stamps = next, dirs
+	return next, true, nil
+}
+
+// retainFiles returns a file set that holds only the files of pkgs, at the
+// positions fset gave them, so that their syntax trees stay valid while the
+// files of re-parsed and deleted packages are released. Newly parsed files
+// are placed after them.
+func retainFiles(fset *token.FileSet, pkgs []*packageNode) *token.FileSet {
+	var files []*token.File
+	for _, pkg := range pkgs {
+		for _, f := range pkg.files {
+			files = append(files, fset.File(f.ast.FileStart))
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Base() < files[j].Base() })
+
+	retained := token.NewFileSet()
+	for _, f := range files {
+		retained.AddFile(f.Name(), f.Base(), f.Size()).SetLines(f.Lines())
+	}
+	return retained
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var cacheModule = map[string]string{
+	"internal/order/domain/order.go": `package domain
+
+type Order struct{ ID string }
+`,
+	"internal/order/application/service.go": `package application
+
+import "example.com/shop/internal/order/domain"
+
+type Service struct{ last domain.Order }
+`,
+	"internal/billing/domain/invoice.go": `package domain
+
+type Invoice struct{ Total int }
+`,
+	"tools/gen.go": `//go:build ignore
+
+package main
+`,
+}
+
+// edit rewrites the file at rel below root and moves its modification time
+// forward, so that the cache sees a change even within the clock's
+// resolution.
+func edit(t *testing.T, root, rel, content string) {
+	t.Helper()
+	writeFiles(t, root, map[string]string{rel: content})
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, filepath.FromSlash(rel)), later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGraphCacheLoad(t *testing.T) {
+	const (
+		order   = "example.com/shop/internal/order/domain"
+		service = "example.com/shop/internal/order/application"
+		billing = "example.com/shop/internal/billing/domain"
+		payment = "example.com/shop/internal/payment/domain"
+	)
+
+	tests := []struct {
+		name     string
+		change   func(t *testing.T, root string)
+		refresh  bool
+		packages []string
+		reparsed []string
+	}{
+		{
+			name:     "no change",
+			change:   func(*testing.T, string) {},
+			packages: []string{billing, service, order},
+		},
+		{
+			name: "edited file",
+			change: func(t *testing.T, root string) {
+				edit(t, root, "internal/order/domain/order.go", "package domain\n\ntype Order struct{ ID, Customer string }\n")
+			},
+			refresh:  true,
+			packages: []string{billing, service, order},
+			reparsed: []string{order},
+		},
+		{
+			name: "added package",
+			change: func(t *testing.T, root string) {
+				edit(t, root, "internal/payment/domain/payment.go", "package domain\n")
+			},
+			refresh:  true,
+			packages: []string{billing, service, order, payment},
+			reparsed: []string{payment},
+		},
+		{
+			name: "deleted package",
+			change: func(t *testing.T, root string) {
+				if err := os.Remove(filepath.Join(root, "internal/billing/domain/invoice.go")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			refresh:  true,
+			packages: []string{service, order},
+		},
+		{
+			name: "file excluded by build constraints",
+			change: func(t *testing.T, root string) {
+				edit(t, root, "tools/gen.go", "//go:build ignore\n\npackage main\n\nfunc main() {}\n")
+			},
+			refresh:  true,
+			packages: []string{billing, service, order},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeModule(t, cacheModule)
+			cache := newGraphCache(root)
+			before, refreshed, err := cache.load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if refreshed {
+				t.Error("the first load reported a refresh")
+			}
+
+			tt.change(t, root)
+			after, refreshed, err := cache.load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if refreshed != tt.refresh || (after == before) == tt.refresh {
+				t.Fatalf("refreshed = %v (same graph %v), want %v", refreshed, after == before, tt.refresh)
+			}
+
+			var got []string
+			for _, pkg := range after.sortedPackages() {
+				got = append(got, pkg.importPath)
+				reused := before.packages[pkg.importPath] == pkg
+				if reused == slices.Contains(tt.reparsed, pkg.importPath) {
+					t.Errorf("%s reused = %v", pkg.importPath, reused)
+				}
+			}
+			slices.Sort(tt.packages)
+			if !slices.Equal(got, tt.packages) {
+				t.Errorf("packages = %q, want %q", got, tt.packages)
+			}
+		})
+	}
+}
+
+func TestGraphCacheReleasesReplacedFiles(t *testing.T) {
+	root := writeModule(t, cacheModule)
+	cache := newGraphCache(root)
+	if _, _, err := cache.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var graph *importGraph
+	for i := range 5 {
+		edit(t, root, "internal/order/domain/order.go", "package domain\n\n"+
+			"// Order is revision "+string(rune('a'+i))+".\ntype Order struct{ ID string }\n")
+		var err error
+		if graph, _, err = cache.load(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := 0
+	graph.fset.Iterate(func(*token.File) bool {
+		files++
+		return true
+	})
+	if files != 3 {
+		t.Errorf("file set holds %d files after 5 edits, want the 3 files of the module", files)
+	}
+
+	// Positions in the syntax of packages that were not re-parsed stay valid.
+	service := graph.packages["example.com/shop/internal/order/application"]
+	if imp := service.files[0].imports[0]; graph.fset.Position(service.files[0].ast.Imports[0].Pos()).Line != imp.line {
+		t.Errorf("import of %s moved to line %d", imp.path, graph.fset.Position(service.files[0].ast.Imports[0].Pos()).Line)
+	}
+	if line := graph.fset.Position(graph.packages["example.com/shop/internal/order/domain"].files[0].ast.Decls[0].Pos()).Line; line != 4 {
+		t.Errorf("re-parsed declaration at line %d, want 4", line)
+	}
+}
+
+func TestGraphCacheReusesTypes(t *testing.T) {
+	const (
+		order   = "example.com/shop/internal/order/domain"
+		service = "example.com/shop/internal/order/application"
+		billing = "example.com/shop/internal/billing/domain"
+	)
+
+	root := writeModule(t, cacheModule)
+	cache := newGraphCache(root)
+	before, _, err := cache.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	typedBefore := before.typedPackages()
+
+	edit(t, root, "internal/order/domain/order.go", "package domain\n\ntype Order struct{ ID, Customer string }\n")
+	// A graph nobody type-checked is skipped over by the next refresh.
+	if _, _, err := cache.load(); err != nil {
+		t.Fatal(err)
+	}
+	edit(t, root, "internal/order/domain/order.go", "package domain\n\ntype Order struct{ Number int }\n")
+	after, _, err := cache.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.previous != before {
+		t.Fatal("the refreshed graph does not reuse the last type-checked graph")
+	}
+	typedAfter := after.typedPackages()
+	if after.previous != nil {
+		t.Error("the previous graph is kept after type checking")
+	}
+
+	if typedAfter[billing] != typedBefore[billing] {
+		t.Error("the unchanged billing package was type-checked again")
+	}
+	if typedAfter[order] == typedBefore[order] || typedAfter[service] == typedBefore[service] {
+		t.Error("the changed package or its importer kept stale types")
+	}
+	last := typedAfter[service].Scope().Lookup("Service").Type().Underlying().String()
+	if want := "struct{last example.com/shop/internal/order/domain.Order}"; last != want {
+		t.Errorf("Service = %s, want %s", last, want)
+	}
+	if field := typedAfter[order].Scope().Lookup("Order").Type().Underlying().String(); field != "struct{Number int}" {
+		t.Errorf("Order = %s", field)
+	}
+}
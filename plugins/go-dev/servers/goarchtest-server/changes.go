@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+// defaultBaseBranches are tried in order to find the merge-base when no base
+// ref is given.
+var defaultBaseBranches = []string{"main", "origin/main", "master", "origin/master"}
+
+// git runs a git command in the project root and returns its trimmed stdout.
+func (s *GoArchTestServer) git(ctx context.Context, args ...string) (string, error) {
+	out, err := s.gitOutput(ctx, args...)
+	return strings.TrimSpace(out), err
+}
+
+// gitOutput runs a git command in the project root and returns its stdout
+// as is.
+func (s *GoArchTestServer) gitOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.projectRoot
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// resolveBase returns the commit to diff against: base itself when given,
+// otherwise the merge-base of HEAD with the first default branch that exists.
+func (s *GoArchTestServer) resolveBase(ctx context.Context, base string) (string, error) {
+	if base != "" {
+		return s.git(ctx, "rev-parse", "--verify", base+"^{commit}")
+	}
+	for _, branch := range defaultBaseBranches {
+		if mergeBase, err := s.git(ctx, "merge-base", "HEAD", branch); err == nil {
+			return mergeBase, nil
+		}
+	}
+	return "", fmt.Errorf("no merge-base with %s; pass base explicitly", strings.Join(defaultBaseBranches, ", "))
+}
+
+// changedGoFiles lists the Go files below the project root that differ from
+// base, including uncommitted and untracked files, relative to the root.
+// Paths are listed NUL-terminated so that git neither quotes nor escapes
+// names with spaces or non-ASCII characters.
+func (s *GoArchTestServer) changedGoFiles(ctx context.Context, base string) ([]string, error) {
+	diff, err := s.gitOutput(ctx, "diff", "-z", "--name-only", "--relative", base, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := s.gitOutput(ctx, "ls-files", "-z", "--others", "--exclude-standard", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, f := range strings.Split(diff+untracked, "\x00") {
+		if strings.HasSuffix(f, ".go") && !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// affectedPackages returns the module-relative paths of the packages that
+// contain a changed file, plus every package that directly imports one of
+// them. Packages whose files were all deleted are dropped.
+func affectedPackages(graph *importGraph, files []string) (touched, affected []string) {
+	set := make(map[string]bool)
+	for _, f := range files {
+		dir := path.Dir(f)
+		if _, ok := graph.packages[graph.importPathOf(dir)]; ok && !set[dir] {
+			set[dir] = true
+			touched = append(touched, dir)
+		}
+	}
+
+	importers := make(map[string]bool)
+	graph.eachInternalImport(func(pkg *packageNode, imp importRef, target string) {
+		if set[target] && !set[pkg.relPath] {
+			importers[pkg.relPath] = true
+		}
+	})
+
+	affected = append(affected, touched...)
+	for rel := range importers {
+		affected = append(affected, rel)
+	}
+	sort.Strings(touched)
+	sort.Strings(affected)
+	return touched, affected
+}
+
+// scopedViolations evaluates the layer, isolation, naming, purity, external
+// and workspace module rules on the given packages only. The rules visit the
+// packages of the graph they are given and resolve imports by path, so a
+// subgraph of the packages yields their violations without a full sweep.
+func scopedViolations(graph *importGraph, rules *archRules, packages []string) []violation {
+	return packageRuleViolations(graph.subgraph(packages), rules, discoveredDomains(graph, rules))
+}
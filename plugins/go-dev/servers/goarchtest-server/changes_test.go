@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestChangedGoFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		"svc/go.mod":                       "module example.com/shop\n",
+		"svc/internal/order/domain/a.go":   "package domain\n",
+		"svc/internal/order/domain/b.go":   "package domain\n",
+		"svc/internal/order/domain/old.go": "package domain\n",
+		"other/main.go":                    "package main\n",
+	})
+	s := &GoArchTestServer{projectRoot: repo}
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		if _, err := s.git(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base, err := s.git(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, repo, map[string]string{
+		"svc/internal/order/domain/a.go":           "package domain\n\ntype A struct{}\n",
+		"svc/internal/order/domain/my order.go":    "package domain\n",
+		"svc/internal/order/domain/größe.go":       "package domain\n",
+		"svc/internal/order/domain/notes.txt":      "not Go\n",
+		"svc/internal/order/domain/domain_test.go": "package domain\n",
+		"other/util.go": "package main\n",
+	})
+	if err := os.Remove(filepath.Join(repo, "svc/internal/order/domain/old.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	s.projectRoot = filepath.Join(repo, "svc")
+	files, err := s.changedGoFiles(ctx, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"internal/order/domain/a.go",
+		"internal/order/domain/domain_test.go",
+		"internal/order/domain/größe.go",
+		"internal/order/domain/my order.go",
+		"internal/order/domain/old.go",
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("changed files = %q, want %q", files, want)
+	}
+}
+
+func TestAffectedPackages(t *testing.T) {
+	tests := []struct {
+		name              string
+		files             []string
+		touched, affected []string
+	}{
+		{
+			name:     "package with importers",
+			files:    []string{"internal/order/domain/order.go", "internal/order/domain/more.go"},
+			touched:  []string{"internal/order/domain"},
+			affected: []string{"internal/order/application", "internal/order/domain", "internal/order/infrastructure"},
+		},
+		{
+			name:     "importers are not followed transitively",
+			files:    []string{"internal/order/infrastructure/repo.go"},
+			touched:  []string{"internal/order/infrastructure"},
+			affected: []string{"cmd/app", "internal/order/domain", "internal/order/infrastructure"},
+		},
+		{
+			name:  "deleted package and files outside packages",
+			files: []string{"internal/payment/domain/payment.go", "tools.go"},
+		},
+	}
+
+	graph := loadModule(t, layeredModule)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			touched, affected := affectedPackages(graph, tt.files)
+			if !slices.Equal(touched, tt.touched) {
+				t.Errorf("touched = %q, want %q", touched, tt.touched)
+			}
+			if !slices.Equal(affected, tt.affected) {
+				t.Errorf("affected = %q, want %q", affected, tt.affected)
+			}
+		})
+	}
+}
+
+func TestScopedViolations(t *testing.T) {
+	graph := loadModule(t, layeredModule)
+	rules := defaultRules()
+	all := packageRuleViolations(graph, rules, discoveredDomains(graph, rules))
+
+	for _, packages := range [][]string{
+		{"internal/order/domain"},
+		{"internal/order/application", "internal/order/events"},
+		{"cmd/app", "internal/billing/application"},
+	} {
+		var want []violation
+		for _, v := range all {
+			if rel, _ := graph.relImport(v.Package); slices.Contains(packages, rel) {
+				want = append(want, v)
+			}
+		}
+		got := scopedViolations(graph, rules, packages)
+		if !slices.Equal(describeViolations(graph, got), describeViolations(graph, want)) {
+			t.Errorf("scoped to %q: %q, want %q", packages, describeViolations(graph, got), describeViolations(graph, want))
+		}
+	}
+}
@@ -0,0 +1,565 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Exit codes of the check, graph and baseline subcommands.
+const (
+	exitOK         = 0
+	exitViolations = 1
+	exitError      = 2
+)
+
+const cliUsage = `Usage: goarchtest-server <command> [flags]
+
+Commands:
+  serve                 Run the MCP server on stdio, HTTP or SSE (default)
+  check                 Run every architecture rule and report violations
+  graph                 Print the domain/layer dependency graph
+  baseline              Record current violations in ` + baselineFileName + `
+  check-domain-purity   Check the imports of Go files in the pure layers
+
+Run "goarchtest-server <command> -h" for the flags of a command.
+`
+
+// runCLI dispatches to a subcommand and returns the process exit code.
+// Without a command the MCP server is started, so existing MCP client
+// configurations keep working; a bare directory argument is still accepted
+// as the project root for the same reason.
+func runCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		return runServe(nil, stderr)
+	}
+
+	switch cmd, rest := args[0], args[1:]; cmd {
+	case "serve":
+		return runServe(rest, stderr)
+	case "check":
+		return runCheck(rest, stdout, stderr)
+	case "graph":
+		return runGraph(rest, stdout, stderr)
+	case "baseline":
+		return runBaseline(rest, stdout, stderr)
+	case "check-domain-purity":
+		return runDomainPurity(rest, stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, cliUsage)
+		return exitOK
+	default:
+		if strings.HasPrefix(cmd, "-") {
+			return runServe(args, stderr)
+		}
+		if info, err := os.Stat(cmd); err == nil && info.IsDir() && len(rest) == 0 {
+			return runServe([]string{"-root", cmd}, stderr)
+		}
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", cmd, cliUsage)
+		return exitError
+	}
+}
+
+// newFlagSet returns the flags shared by every subcommand and the --root
+// flag they all accept.
+func newFlagSet(name, usage string, stderr io.Writer) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: goarchtest-server %s\n\n", usage)
+		fs.PrintDefaults()
+	}
+	root := fs.String("root", "", "Go module or go.work workspace root to analyze (default: current directory)")
+	return fs, root
+}
+
+// parseFlags parses args and rejects positional arguments. The returned
+// code is only meaningful when ok is false.
+func parseFlags(fs *flag.FlagSet, args []string) (code int, ok bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitError, false
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return exitError, false
+	}
+	return 0, true
+}
+
+// runServe runs the MCP server on stdio, or with -transport http or sse as
+// a long-lived server that several clients share.
+func runServe(args []string, stderr io.Writer) int {
+	fs, root := newFlagSet("serve", "serve [-root dir] [-transport stdio|http|sse] [-addr host:port] [-token secret]", stderr)
+	transport := fs.String("transport", transportStdio, "MCP transport: stdio, http (streamable HTTP) or sse")
+	addr := fs.String("addr", defaultAddr, "listen address of the http and sse transports")
+	token := fs.String("token", "", "bearer token required by the http and sse transports (default: $"+tokenEnv+")")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !slices.Contains([]string{transportStdio, transportHTTP, transportSSE}, *transport) {
+		fmt.Fprintf(stderr, "unsupported transport %q, expected stdio, http or sse\n", *transport)
+		return exitError
+	}
+	if *token == "" {
+		*token = os.Getenv(tokenEnv)
+	}
+
+	s := NewGoArchTestServer(*root)
+	var err error
+	if *transport == transportStdio {
+		fmt.Fprintln(stderr, "GoArchTest MCP server running")
+		err = server.ServeStdio(s.mcpServer)
+	} else {
+		err = serveHTTP(s.mcpServer, *transport, *addr, *token, stderr)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Server error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// runCheck runs every rule, or with -changed only the rules attributed to
+// changed packages and their importers, and exits with 1 when an error
+// violation remains after the baseline. In a go.work workspace every module,
+// or the one named by -module, is checked and the output is grouped by
+// module; sarif and junit reports cover the whole workspace.
+func runCheck(args []string, stdout, stderr io.Writer) int {
+	fs, root := newFlagSet("check", "check [-root dir] [-module path] [-format text|json|sarif|junit] [-domain name] [-changed ref] [-baseline=false]", stderr)
+	module := fs.String("module", "", "in a go.work workspace, only check this module (module path or directory)")
+	format := fs.String("format", "text", "output format: text, json, sarif (SARIF 2.1.0) or junit (JUnit XML)")
+	domain := fs.String("domain", "", "only report violations raised by packages of this domain")
+	changed := fs.String("changed", "", "only check packages changed since this git ref and their direct importers")
+	useBaseline := fs.Bool("baseline", true, "hide violations recorded in "+baselineFileName)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !slices.Contains([]string{"text", "json", "sarif", "junit"}, *format) {
+		fmt.Fprintf(stderr, "unsupported format %q, expected text, json, sarif or junit\n", *format)
+		return exitError
+	}
+
+	s := NewGoArchTestServer(*root)
+	servers, err := s.moduleServers(*module)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return exitError
+	}
+
+	var checks []moduleCheck
+	var domains []string
+	passed := true
+	for _, m := range servers {
+		c, err := checkModule(m, *changed, *domain, *useBaseline)
+		if err != nil {
+			if s.workspace != nil {
+				fmt.Fprintf(stderr, "Error in %s: %v\n", m.module.Dir, err)
+			} else {
+				fmt.Fprintf(stderr, "Error %v\n", err)
+			}
+			return exitError
+		}
+		checks = append(checks, c)
+		domains = append(domains, c.domains...)
+		passed = passed && c.report.Passed
+	}
+	slices.Sort(domains)
+	domains = slices.Compact(domains)
+	if *domain != "" && !slices.Contains(domains, *domain) {
+		fmt.Fprintf(stderr, "unknown domain %q, expected one of: %s\n", *domain, strings.Join(domains, ", "))
+		return exitError
+	}
+
+	switch {
+	case *format == "sarif" || *format == "junit":
+		var data []byte
+		if s.workspace == nil {
+			c := checks[0]
+			data, err = renderReport(*format, c.report, c.graph, c.rules, c.checkedDomains(*domain))
+		} else {
+			data, err = renderWorkspaceReport(*format, checks, *domain)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "Error rendering %s report: %v\n", *format, err)
+			return exitError
+		}
+		stdout.Write(data)
+	case *format == "json" && s.workspace == nil:
+		if err := writeJSON(stdout, checks[0].result()); err != nil {
+			fmt.Fprintf(stderr, "Error encoding report: %v\n", err)
+			return exitError
+		}
+	case *format == "json":
+		type moduleOutput struct {
+			Module string `json:"module"`
+			Dir    string `json:"dir"`
+			checkResult
+		}
+		grouped := struct {
+			Check   string         `json:"check"`
+			Passed  bool           `json:"passed"`
+			Modules []moduleOutput `json:"modules"`
+		}{"check", passed, []moduleOutput{}}
+		for _, c := range checks {
+			grouped.Modules = append(grouped.Modules, moduleOutput{c.module.Path, c.module.Dir, c.result()})
+		}
+		if err := writeJSON(stdout, grouped); err != nil {
+			fmt.Fprintf(stderr, "Error encoding report: %v\n", err)
+			return exitError
+		}
+	default:
+		if passed {
+			fmt.Fprintln(stdout, "✅ Architecture check passed")
+		} else {
+			fmt.Fprintln(stdout, "❌ Architecture check failed")
+		}
+		for _, c := range checks {
+			if s.workspace != nil {
+				fmt.Fprintf(stdout, "\n📦 %s (%s)\n", c.module.Path, c.module.Dir)
+			}
+			c.writeText(stdout)
+		}
+	}
+
+	if !passed {
+		return exitViolations
+	}
+	return exitOK
+}
+
+// moduleCheck is the outcome of the check command on one module.
+type moduleCheck struct {
+	module  *workspaceModule
+	graph   *importGraph
+	rules   *archRules
+	domains []string
+	scope   string
+	report  checkReport
+}
+
+// checkResult is the JSON output of the check command for one module.
+type checkResult struct {
+	checkReport
+	Domains []string `json:"domains"`
+	Scope   string   `json:"scope"`
+}
+
+// checkModule runs the check command on the module of s. Violations are
+// narrowed to domain when it is set, whether or not the module has it.
+func checkModule(s *GoArchTestServer, changed, domain string, useBaseline bool) (moduleCheck, error) {
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return moduleCheck{}, fmt.Errorf("loading packages: %w", err)
+	}
+
+	domains, violations := runAllRules(graph, rules)
+	scope := "all packages"
+	if changed != "" {
+		ctx := context.Background()
+		base, err := s.resolveBase(ctx, changed)
+		if err != nil {
+			return moduleCheck{}, fmt.Errorf("resolving base ref: %w", err)
+		}
+		files, err := s.changedGoFiles(ctx, base)
+		if err != nil {
+			return moduleCheck{}, fmt.Errorf("listing changed files: %w", err)
+		}
+		_, affected := affectedPackages(graph, files)
+		violations = scopedViolations(graph, rules, affected)
+		scope = fmt.Sprintf("%d package(s) changed since %.12s and their importers", len(affected), base)
+	}
+	if domain != "" {
+		violations = slices.DeleteFunc(violations, func(v violation) bool {
+			rel, _ := graph.relImport(v.Package)
+			d, _ := rules.classify(rel)
+			return d != domain
+		})
+	}
+
+	report := newCheckReport("check", violations)
+	ignoreSuppressed(graph, &report)
+	if useBaseline {
+		if err := s.subtractBaseline(graph, rules, &report); err != nil {
+			return moduleCheck{}, fmt.Errorf("applying baseline: %w", err)
+		}
+	}
+	report.ParseErrors = graph.parseErrors()
+	return moduleCheck{s.module, graph, rules, domains, scope, report}, nil
+}
+
+// checkedDomains returns the domains the check covered.
+func (c moduleCheck) checkedDomains(domain string) []string {
+	if domain == "" {
+		return c.domains
+	}
+	if slices.Contains(c.domains, domain) {
+		return []string{domain}
+	}
+	return nil
+}
+
+func (c moduleCheck) result() checkResult {
+	return checkResult{c.report, append([]string{}, c.domains...), c.scope}
+}
+
+func (c moduleCheck) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Domains: %s\nRules: %s\nScope: %s\n", strings.Join(c.domains, ", "), c.rules.describe(), c.scope)
+	if len(c.report.Violations) > 0 {
+		fmt.Fprintf(w, "\n%d rule violation(s):\n%s\n", c.report.Summary.Total, formatViolations(c.report.Violations))
+	}
+	if note := c.report.filterNote(); note != "" {
+		fmt.Fprintln(w, strings.TrimLeft(note, "\n"))
+	}
+}
+
+func runGraph(args []string, stdout, stderr io.Writer) int {
+	fs, root := newFlagSet("graph", "graph [-root dir] [-module path] [-format dot|mermaid|json] [-domain name] [-o file]", stderr)
+	module := fs.String("module", "", "in a go.work workspace, the module to draw (module path or directory)")
+	format := fs.String("format", "dot", "output format: dot, mermaid or json")
+	domain := fs.String("domain", "", "only show this domain and the edges touching it")
+	output := fs.String("o", "", "write the graph to this file instead of stdout")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	s := NewGoArchTestServer(*root)
+	servers, err := s.moduleServers(*module)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return exitError
+	}
+	if len(servers) > 1 {
+		fmt.Fprintf(stderr, "%s is a %s workspace, pick a module with -module: %s\n", s.projectRoot, workspaceFileName, strings.Join(s.workspace.dirs(), ", "))
+		return exitError
+	}
+	graph, rules, err := servers[0].analyze()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading packages: %v\n", err)
+		return exitError
+	}
+
+	lg := buildLayerGraph(graph, rules, *domain)
+	var rendered string
+	switch *format {
+	case "dot":
+		rendered = lg.dot()
+	case "mermaid":
+		rendered = lg.mermaid()
+	case "json":
+		if rendered, err = lg.json(); err != nil {
+			fmt.Fprintf(stderr, "Error encoding graph: %v\n", err)
+			return exitError
+		}
+		rendered += "\n"
+	default:
+		fmt.Fprintf(stderr, "unsupported format %q, expected dot, mermaid or json\n", *format)
+		return exitError
+	}
+
+	if *output == "" {
+		fmt.Fprint(stdout, rendered)
+		return exitOK
+	}
+	if err := os.WriteFile(*output, []byte(rendered), 0o644); err != nil {
+		fmt.Fprintf(stderr, "Error writing graph: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintf(stdout, "%d nodes, %d edges, %d illegal\nGraph saved to: %s\n", len(lg.Nodes), len(lg.Edges), lg.illegalEdges(), *output)
+	return exitOK
+}
+
+func runBaseline(args []string, stdout, stderr io.Writer) int {
+	fs, root := newFlagSet("baseline", "baseline [-root dir] [-module path] [-prune]", stderr)
+	module := fs.String("module", "", "in a go.work workspace, only record the baseline of this module (module path or directory)")
+	prune := fs.Bool("prune", false, "only remove fixed entries, never add new violations")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	s := NewGoArchTestServer(*root)
+	servers, err := s.moduleServers(*module)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return exitError
+	}
+	// Every module of a workspace keeps its own baseline.
+	for _, m := range servers {
+		prefix := ""
+		if s.workspace != nil {
+			prefix = m.module.Dir + ": "
+		}
+		recorded, removed, err := m.recordBaseline(*prune)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error creating baseline: %s%v\n", prefix, err)
+			return exitError
+		}
+		fmt.Fprintln(stdout, "✅ "+prefix+baselineMessage(recorded, removed, *prune))
+	}
+	return exitOK
+}
+
+func writeJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// hookInput is the part of a Claude Code hook payload the CLI reads from
+// stdin when no files are given on the command line.
+type hookInput struct {
+	Cwd       string `json:"cwd"`
+	ToolInput struct {
+		FilePath string `json:"file_path"`
+	} `json:"tool_input"`
+}
+
+// runDomainPurity implements the check-domain-purity subcommand. Its exit
+// codes follow the hook protocol rather than the other commands: 0 when
+// every file is pure, 2 with the violations on stderr so that a PostToolUse
+// hook hands them back to the agent, and 1 on errors.
+func runDomainPurity(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("check-domain-purity", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	root := fs.String("root", "", "module root (default: the nearest directory with a go.mod above each file)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: goarchtest-server check-domain-purity [-root dir] [file.go ...]")
+		fmt.Fprintln(stderr, "\nChecks the imports of Go files in the pure layers. Without files, reads the")
+		fmt.Fprintln(stderr, "edited file from a Claude Code hook payload on stdin.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		var in hookInput
+		if err := json.NewDecoder(stdin).Decode(&in); err != nil || in.ToolInput.FilePath == "" {
+			fmt.Fprintln(stderr, "check-domain-purity: no files given and no hook payload with tool_input.file_path on stdin")
+			return 1
+		}
+		file := in.ToolInput.FilePath
+		if !filepath.IsAbs(file) && in.Cwd != "" {
+			file = filepath.Join(in.Cwd, file)
+		}
+		files = []string{file}
+	}
+
+	var violations []violation
+	for _, file := range files {
+		found, err := fileDomainPurity(file, *root)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error checking %s: %v\n", file, err)
+			return 1
+		}
+		violations = append(violations, found...)
+	}
+
+	if len(violations) == 0 {
+		fmt.Fprintln(stdout, "✅ Domain layer purity validated")
+		return 0
+	}
+	report := newCheckReport("check_domain_purity", violations)
+	fmt.Fprintf(stderr, "❌ Domain purity violations found:\n%s\n", formatViolations(report.Violations))
+	return 2
+}
+
+// fileDomainPurity checks one Go file against the purity and layer rules of
+// its module, minus the module's baseline. Test files, deleted files and files
+// outside the pure layers are skipped.
+func fileDomainPurity(file, root string) ([]violation, error) {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+		return nil, nil
+	}
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if root == "" {
+		if root, err = findModuleRoot(filepath.Dir(file)); err != nil {
+			return nil, err
+		}
+	}
+	var rules *archRules
+	var problems []string
+	if m := workspaceModuleAt(root); m != nil {
+		rules, problems, err = loadModuleRules(root, m.workspace)
+	} else {
+		rules, problems, err = loadRules(root)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid rules file:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	relDir, err := filepath.Rel(root, filepath.Dir(file))
+	if err != nil || relDir == ".." || strings.HasPrefix(relDir, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("file is outside the module at %s", root)
+	}
+	relDir = filepath.ToSlash(relDir)
+	if _, layer := rules.classify(relDir); !slices.Contains(rules.pureLayers(), layer) {
+		return nil, nil
+	}
+
+	modulePath, err := readModulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	graph := newImportGraph(root, modulePath, token.NewFileSet())
+	pkg, err := graph.parsePackage(relDir, []fileStamp{{name: filepath.Base(file)}})
+	if err != nil {
+		return nil, err
+	}
+	graph.packages[pkg.importPath] = pkg
+
+	sups, err := packageSuppressions(graph, pkg, filepath.Base(file))
+	if err != nil {
+		return nil, err
+	}
+	violations, _ := suppressViolations(sups, domainPurityViolations(graph, rules, ""))
+	b, err := readBaseline(filepath.Join(root, baselineFileName))
+	if err != nil || b == nil {
+		return violations, err
+	}
+	remaining, _ := b.subtract(violations)
+	return remaining, nil
+}
+
+// findModuleRoot returns the nearest directory at or above dir that holds a
+// go.mod file.
+func findModuleRoot(dir string) (string, error) {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("no go.mod found above %s", dir)
+		}
+	}
+}
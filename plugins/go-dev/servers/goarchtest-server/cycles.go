@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const ruleCycle = "cycle"
+
+// importStep is one edge of an import chain.
+type importStep struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// importCycle is a strongly connected component of the package or domain
+// graph. Path is one concrete loop through the component, starting and
+// ending at the same node, and Chain the imports that form it.
+type importCycle struct {
+	Level   string       `json:"level"`
+	Members []string     `json:"members"`
+	Path    []string     `json:"path"`
+	Chain   []importStep `json:"chain"`
+}
+
+func (c importCycle) path() string {
+	return strings.Join(c.Path, " → ")
+}
+
+// digraph is a directed graph keyed by node name whose edges carry the
+// import chain that creates them.
+type digraph map[string]map[string][]importStep
+
+func (g digraph) addEdge(from, to string, chain []importStep) {
+	if g[from] == nil {
+		g[from] = make(map[string][]importStep)
+	}
+	if _, ok := g[from][to]; !ok {
+		g[from][to] = chain
+	}
+	if g[to] == nil {
+		g[to] = make(map[string][]importStep)
+	}
+}
+
+func (g digraph) nodes() []string {
+	nodes := make([]string, 0, len(g))
+	for n := range g {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func (g digraph) successors(n string) []string {
+	succ := make([]string, 0, len(g[n]))
+	for s := range g[n] {
+		succ = append(succ, s)
+	}
+	sort.Strings(succ)
+	return succ
+}
+
+// stronglyConnected returns the components of g with more than one node,
+// using Tarjan's algorithm. Members of each component are sorted.
+func (g digraph) stronglyConnected() [][]string {
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+	next := 0
+
+	var visit func(n string)
+	visit = func(n string) {
+		index[n], low[n] = next, next
+		next++
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, m := range g.successors(n) {
+			if _, seen := index[m]; !seen {
+				visit(m)
+				low[n] = min(low[n], low[m])
+			} else if onStack[m] {
+				low[n] = min(low[n], index[m])
+			}
+		}
+
+		if low[n] != index[n] {
+			return
+		}
+		var component []string
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[m] = false
+			component = append(component, m)
+			if m == n {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, n := range g.nodes() {
+		if _, seen := index[n]; !seen {
+			visit(n)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	return components
+}
+
+// shortestCycle finds the shortest loop through start that stays inside
+// component. It returns the nodes of the loop and the concatenated import
+// chain of its edges.
+func (g digraph) shortestCycle(start string, component []string) ([]string, []importStep) {
+	inComponent := make(map[string]bool, len(component))
+	for _, n := range component {
+		inComponent[n] = true
+	}
+
+	parent := make(map[string]string)
+	queue := []string{start}
+	visited := map[string]bool{start: true}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range g.successors(n) {
+			if !inComponent[m] {
+				continue
+			}
+			if m == start {
+				loop := []string{start}
+				for cur := n; cur != start; cur = parent[cur] {
+					loop = append(loop, cur)
+				}
+				loop = append(loop, start)
+				slices.Reverse(loop)
+
+				var chain []importStep
+				for i := 0; i < len(loop)-1; i++ {
+					chain = append(chain, g[loop[i]][loop[i+1]]...)
+				}
+				return loop, chain
+			}
+			if !visited[m] {
+				visited[m] = true
+				parent[m] = n
+				queue = append(queue, m)
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (g digraph) cycles(level string) []importCycle {
+	var cycles []importCycle
+	for _, component := range g.stronglyConnected() {
+		loop, chain := g.shortestCycle(component[0], component)
+		cycles = append(cycles, importCycle{
+			Level:   level,
+			Members: component,
+			Path:    loop,
+			Chain:   chain,
+		})
+	}
+	return cycles
+}
+
+// packageGraph is the module's import graph over module-relative paths.
+func packageGraph(graph *importGraph) digraph {
+	g := make(digraph)
+	for _, pkg := range graph.sortedPackages() {
+		if g[pkg.relPath] == nil {
+			g[pkg.relPath] = make(map[string][]importStep)
+		}
+	}
+	graph.eachInternalImport(func(pkg *packageNode, imp importRef, target string) {
+		g.addEdge(pkg.relPath, target, []importStep{{From: pkg.relPath, To: target, File: imp.file, Line: imp.line}})
+	})
+	return g
+}
+
+// domainGraph links domain A to domain B when a package of A reaches a
+// package of B directly or through packages that belong to no domain, such
+// as shared helpers. Each edge keeps the first such chain found.
+func domainGraph(graph *importGraph, rules *archRules) digraph {
+	pg := packageGraph(graph)
+	domainOf := func(rel string) string {
+		d, _ := rules.classify(rel)
+		return d
+	}
+
+	g := make(digraph)
+	for _, start := range pg.nodes() {
+		from := domainOf(start)
+		if from == "" {
+			continue
+		}
+
+		chains := map[string][]importStep{start: nil}
+		queue := []string{start}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			for _, m := range pg.successors(n) {
+				if _, seen := chains[m]; seen {
+					continue
+				}
+				chain := append(append([]importStep{}, chains[n]...), pg[n][m]...)
+				chains[m] = chain
+
+				switch to := domainOf(m); to {
+				case "":
+					queue = append(queue, m)
+				case from:
+				default:
+					g.addEdge(from, to, chain)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// detectCycles reports cycles at the requested level: "package", "domain"
+// or "all".
+func detectCycles(graph *importGraph, rules *archRules, level string) []importCycle {
+	var cycles []importCycle
+	if level == "all" || level == "package" {
+		cycles = append(cycles, packageGraph(graph).cycles("package")...)
+	}
+	if level == "all" || level == "domain" {
+		cycles = append(cycles, domainGraph(graph, rules).cycles("domain")...)
+	}
+	return cycles
+}
+
+// cycleViolations returns one violation per cycle, in the order of cycles,
+// located at the first import of its chain. A cycle without a chain, which
+// digraph.cycles never returns, is reported without a location.
+func cycleViolations(graph *importGraph, cycles []importCycle) []violation {
+	violations := make([]violation, 0, len(cycles))
+	for _, c := range cycles {
+		v := violation{
+			Rule:     ruleCycle,
+			Severity: severityError,
+			Message:  fmt.Sprintf("%s cycle: %s", c.Level, c.path()),
+		}
+		if len(c.Chain) > 0 {
+			first := c.Chain[0]
+			v.Package = graph.importPathOf(first.From)
+			v.Import = graph.importPathOf(first.To)
+			v.File, v.Line = first.File, first.Line
+		}
+		if c.Level == "domain" {
+			v.Fix = fmt.Sprintf("Break the cycle between %s with a port, a domain event or a shared contract so dependencies point one way", strings.Join(c.Members, ", "))
+		} else {
+			v.Fix = "Move the shared types into a package both sides can import, or invert one import behind an interface"
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+// survivingCycles returns the cycles whose violation is among surviving,
+// the violations left after suppressions and the baseline. violations is
+// aligned with cycles, and each surviving violation accounts for a single
+// cycle, so cycles reported with the same message are kept apart.
+func survivingCycles(cycles []importCycle, violations, surviving []violation) []importCycle {
+	left := slices.Clone(surviving)
+	var remaining []importCycle
+	for i, v := range violations {
+		if j := slices.Index(left, v); j >= 0 {
+			left = slices.Delete(left, j, j+1)
+			remaining = append(remaining, cycles[i])
+		}
+	}
+	return remaining
+}
+
+func formatCycles(cycles []importCycle) string {
+	var b strings.Builder
+	for i, c := range cycles {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  %s cycle: %s\n", c.Level, c.path())
+		for _, step := range c.Chain {
+			fmt.Fprintf(&b, "    %s:%d: %s imports %s\n", step.File, step.Line, step.From, step.To)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
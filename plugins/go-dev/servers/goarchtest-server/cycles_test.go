@@ -0,0 +1,127 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+var cyclicModule = map[string]string{
+	"pkg/a/a.go": "package a\n\nimport _ \"example.com/shop/pkg/b\"\n",
+	"pkg/b/b.go": "package b\n\nimport _ \"example.com/shop/pkg/c\"\n",
+	"pkg/c/c.go": "package c\n\nimport _ \"example.com/shop/pkg/a\"\n",
+	"pkg/d/d.go": "package d\n\nimport _ \"example.com/shop/pkg/a\"\n",
+	"internal/order/application/service.go": `package application
+
+import _ "example.com/shop/internal/shared/bus"
+`,
+	"internal/shared/bus/bus.go": `package bus
+
+import _ "example.com/shop/internal/billing/domain"
+`,
+	"internal/billing/application/billing.go": `package application
+
+import _ "example.com/shop/internal/order/domain"
+`,
+	"internal/billing/domain/invoice.go": "package domain\n",
+	"internal/order/domain/order.go":     "package domain\n",
+}
+
+func TestDetectCycles(t *testing.T) {
+	const (
+		packageCycle = "package cycle: pkg/a → pkg/b → pkg/c → pkg/a"
+		domainCycle  = "domain cycle: billing → order → billing"
+	)
+	tests := []struct {
+		level string
+		want  []string
+	}{
+		{level: "all", want: []string{packageCycle, domainCycle}},
+		{level: "package", want: []string{packageCycle}},
+		{level: "domain", want: []string{domainCycle}},
+	}
+
+	graph := loadModule(t, cyclicModule)
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			cycles := detectCycles(graph, defaultRules(), tt.level)
+			violations := cycleViolations(graph, cycles)
+			if len(violations) != len(cycles) {
+				t.Fatalf("%d violations for %d cycles", len(violations), len(cycles))
+			}
+			var got []string
+			for i, v := range violations {
+				got = append(got, v.Message)
+				if first := cycles[i].Chain[0]; v.File != first.File || v.Line != first.Line {
+					t.Errorf("%s is located at %s:%d, want %s:%d", v.Message, v.File, v.Line, first.File, first.Line)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("cycles = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDomainCycleChain(t *testing.T) {
+	graph := loadModule(t, cyclicModule)
+	cycles := detectCycles(graph, defaultRules(), "domain")
+	if len(cycles) != 1 {
+		t.Fatalf("found %d domain cycles, want 1", len(cycles))
+	}
+
+	var got []string
+	for _, step := range cycles[0].Chain {
+		got = append(got, step.From+" → "+step.To)
+	}
+	want := []string{
+		"internal/billing/application → internal/order/domain",
+		"internal/order/application → internal/shared/bus",
+		"internal/shared/bus → internal/billing/domain",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("chain = %q, want %q", got, want)
+	}
+	if members := cycles[0].Members; !slices.Equal(members, []string{"billing", "order"}) {
+		t.Errorf("members = %q, want billing and order", members)
+	}
+}
+
+func TestCycleViolationsWithoutChain(t *testing.T) {
+	graph := loadModule(t, cyclicModule)
+	violations := cycleViolations(graph, []importCycle{{Level: "package", Members: []string{"pkg/a", "pkg/b"}, Path: []string{"pkg/a", "pkg/b", "pkg/a"}}})
+	if len(violations) != 1 || violations[0].File != "" || violations[0].Message != "package cycle: pkg/a → pkg/b → pkg/a" {
+		t.Errorf("violations = %+v, want one package cycle without a location", violations)
+	}
+}
+
+func TestSurvivingCycles(t *testing.T) {
+	cycles := []importCycle{
+		{Level: "package", Members: []string{"x"}},
+		{Level: "package", Members: []string{"y"}},
+		{Level: "package", Members: []string{"z"}},
+	}
+	same := violation{Rule: ruleCycle, Message: "package cycle: a → b → a"}
+	other := violation{Rule: ruleCycle, Message: "package cycle: c → d → c"}
+	violations := []violation{same, other, same}
+
+	tests := []struct {
+		name      string
+		surviving []violation
+		want      []string
+	}{
+		{name: "all survive", surviving: []violation{other, same, same}, want: []string{"x", "y", "z"}},
+		{name: "one of two identical violations", surviving: []violation{same}, want: []string{"x"}},
+		{name: "none survive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range survivingCycles(cycles, violations, tt.surviving) {
+				got = append(got, c.Members[0])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("surviving cycles = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// layerGraph collapses the package import graph to one node per
+// internal/<domain>/<layer> namespace.
+type layerGraph struct {
+	Nodes []layerNode `json:"nodes"`
+	Edges []layerEdge `json:"edges"`
+}
+
+type layerNode struct {
+	ID       string `json:"id"`
+	Domain   string `json:"domain"`
+	Layer    string `json:"layer"`
+	Packages int    `json:"packages"`
+}
+
+type layerEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Imports int    `json:"imports"`
+	Illegal bool   `json:"illegal"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// buildLayerGraph aggregates graph into a layerGraph using the project's
+// rules. Packages that belong to a domain but to no layer are grouped into a
+// node named after the domain. When domain is set, only that domain's nodes
+// and the edges touching them are kept.
+func buildLayerGraph(graph *importGraph, rules *archRules, domain string) *layerGraph {
+	nodes := make(map[string]*layerNode)
+	edges := make(map[[2]string]*layerEdge)
+
+	addNode := func(d, l string) (string, bool) {
+		if d == "" && l == "" {
+			return "", false
+		}
+		id := strings.Trim(d+"/"+l, "/")
+		if _, ok := nodes[id]; !ok {
+			nodes[id] = &layerNode{ID: id, Domain: d, Layer: l}
+		}
+		return id, true
+	}
+
+	for _, pkg := range graph.sortedPackages() {
+		d, l := rules.classify(pkg.relPath)
+		if domain != "" && d != domain {
+			continue
+		}
+		if id, ok := addNode(d, l); ok {
+			nodes[id].Packages++
+		}
+	}
+
+	graph.eachInternalImport(func(pkg *packageNode, imp importRef, target string) {
+		fromDomain, fromLayer := rules.classify(pkg.relPath)
+		toDomain, toLayer := rules.classify(target)
+		if fromDomain == toDomain && fromLayer == toLayer {
+			return
+		}
+		if domain != "" && fromDomain != domain && toDomain != domain {
+			return
+		}
+
+		from, ok := addNode(fromDomain, fromLayer)
+		if !ok {
+			return
+		}
+		to, ok := addNode(toDomain, toLayer)
+		if !ok {
+			return
+		}
+
+		edge, ok := edges[[2]string{from, to}]
+		if !ok {
+			edge = &layerEdge{From: from, To: to}
+			edge.Reason = illegalEdgeReason(rules, fromDomain, fromLayer, toDomain, toLayer)
+			edge.Illegal = edge.Reason != ""
+			edges[[2]string{from, to}] = edge
+		}
+		edge.Imports++
+	})
+
+	lg := &layerGraph{Nodes: []layerNode{}, Edges: []layerEdge{}}
+	for _, n := range nodes {
+		lg.Nodes = append(lg.Nodes, *n)
+	}
+	for _, e := range edges {
+		lg.Edges = append(lg.Edges, *e)
+	}
+	sort.Slice(lg.Nodes, func(i, j int) bool { return lg.Nodes[i].ID < lg.Nodes[j].ID })
+	sort.Slice(lg.Edges, func(i, j int) bool {
+		if lg.Edges[i].From != lg.Edges[j].From {
+			return lg.Edges[i].From < lg.Edges[j].From
+		}
+		return lg.Edges[i].To < lg.Edges[j].To
+	})
+	return lg
+}
+
+// illegalEdgeReason explains why a layer-to-layer edge breaks the
+// architecture, or returns "" when the edge is allowed.
+func illegalEdgeReason(rules *archRules, fromDomain, fromLayer, toDomain, toLayer string) string {
+	if rules.domainsIsolated(fromDomain, toDomain) {
+		return fmt.Sprintf("domain %s must not depend on domain %s", fromDomain, toDomain)
+	}
+	if !rules.layerAllowed(fromLayer, toLayer) {
+		return fmt.Sprintf("%s layer must not depend on %s layer", fromLayer, toLayer)
+	}
+	return ""
+}
+
+// label is the node's layer, or its domain for packages outside any layer.
+func (n layerNode) label() string {
+	if n.Layer == "" {
+		return n.Domain
+	}
+	return n.Layer
+}
+
+func (lg *layerGraph) dot() string {
+	var b strings.Builder
+	b.WriteString("digraph architecture {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+
+	for _, domain := range lg.domains() {
+		indent := "  "
+		if domain != "" {
+			fmt.Fprintf(&b, "  subgraph %q {\n", "cluster_"+domain)
+			fmt.Fprintf(&b, "    label=%q;\n", domain)
+			indent = "    "
+		}
+		for _, n := range lg.Nodes {
+			if n.Domain == domain {
+				fmt.Fprintf(&b, "%s%q [label=%q];\n", indent, n.ID, n.label())
+			}
+		}
+		if domain != "" {
+			b.WriteString("  }\n")
+		}
+	}
+
+	for _, e := range lg.Edges {
+		if e.Illegal {
+			fmt.Fprintf(&b, "  %q -> %q [color=red, penwidth=2, label=%q];\n", e.From, e.To, e.Reason)
+		} else {
+			fmt.Fprintf(&b, "  %q -> %q;\n", e.From, e.To)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (lg *layerGraph) mermaid() string {
+	ids := make(map[string]string, len(lg.Nodes))
+	for i, n := range lg.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, domain := range lg.domains() {
+		indent := "  "
+		if domain != "" {
+			fmt.Fprintf(&b, "  subgraph d%d[\"%s\"]\n", i, domain)
+			indent = "    "
+		}
+		for _, n := range lg.Nodes {
+			if n.Domain == domain {
+				fmt.Fprintf(&b, "%s%s[\"%s\"]\n", indent, ids[n.ID], n.label())
+			}
+		}
+		if domain != "" {
+			b.WriteString("  end\n")
+		}
+	}
+
+	var illegal []int
+	for i, e := range lg.Edges {
+		if e.Illegal {
+			fmt.Fprintf(&b, "  %s -->|illegal| %s\n", ids[e.From], ids[e.To])
+			illegal = append(illegal, i)
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+		}
+	}
+	for _, i := range illegal {
+		fmt.Fprintf(&b, "  linkStyle %d stroke:#d00,stroke-width:2px\n", i)
+	}
+	return b.String()
+}
+
+func (lg *layerGraph) json() (string, error) {
+	data, err := json.MarshalIndent(lg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (lg *layerGraph) domains() []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, n := range lg.Nodes {
+		if !seen[n.Domain] {
+			seen[n.Domain] = true
+			domains = append(domains, n.Domain)
+		}
+	}
+	return domains
+}
+
+func (lg *layerGraph) illegalEdges() int {
+	count := 0
+	for _, e := range lg.Edges {
+		if e.Illegal {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const ruleExternalDeps = "external-deps"
+
+// externalPolicy is one module policy of the rules file together with the
+// go.mod requirements it covers.
+type externalPolicy struct {
+	Module   string   `json:"module"`
+	Layers   []string `json:"layers"`
+	Paths    []string `json:"paths"`
+	Reason   string   `json:"reason,omitempty"`
+	Requires []string `json:"requires"`
+}
+
+// externalViolations reports imports of packages outside the module that
+// an external policy covers, made from a layer the policy does not allow.
+// The first policy matching an import decides. Packages outside every
+// layer are unconstrained, as are imports no policy matches.
+func externalViolations(graph *importGraph, rules *archRules) []violation {
+	if len(rules.External) == 0 {
+		return nil
+	}
+	// Without go.mod requirements violations name the import path instead.
+	requires, _ := moduleRequires(graph)
+
+	var violations []violation
+	for _, pkg := range graph.sortedPackages() {
+		_, layer := rules.classify(pkg.relPath)
+		if layer == "" {
+			continue
+		}
+		for _, f := range pkg.files {
+			for _, imp := range f.imports {
+				if _, internal := graph.relImport(imp.path); internal {
+					continue
+				}
+				policy, ok := rules.externalPolicy(imp.path)
+				if !ok || policy.allows(layer, pkg.relPath) {
+					continue
+				}
+
+				module := requiredModule(requires, imp.path)
+				if module == "" {
+					module = imp.path
+				}
+				message := fmt.Sprintf("%s layer must not use module %s, which is only allowed in %s", layer, module, policy.allowedIn())
+				if policy.Reason != "" {
+					message += " (" + policy.Reason + ")"
+				}
+				violations = append(violations, violation{
+					Rule:     ruleExternalDeps,
+					Severity: severityError,
+					Package:  pkg.importPath,
+					Import:   imp.path,
+					File:     imp.file,
+					Line:     imp.line,
+					Message:  message,
+					Fix:      fmt.Sprintf("Move the code that uses %s into %s and reach it through a port", module, policy.allowedIn()),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// externalPolicies lists the module policies with the go.mod requirements
+// each one covers.
+func externalPolicies(rules *archRules, requires []string) []externalPolicy {
+	policies := make([]externalPolicy, len(rules.External))
+	for i, e := range rules.External {
+		p := externalPolicy{
+			Module:   e.Module,
+			Layers:   append([]string{}, e.Layers...),
+			Paths:    append([]string{}, e.Paths...),
+			Reason:   e.Reason,
+			Requires: []string{},
+		}
+		for _, req := range requires {
+			if _, ok := matchPath(e.Module, req); ok {
+				p.Requires = append(p.Requires, req)
+			}
+		}
+		policies[i] = p
+	}
+	return policies
+}
+
+// allows reports whether a package in layer at the module-relative path
+// rel may import the modules of e.
+func (e externalRule) allows(layer, rel string) bool {
+	if slices.Contains(e.Layers, layer) {
+		return true
+	}
+	for _, p := range e.Paths {
+		if _, ok := matchPath(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedIn describes where e allows its modules.
+func (e externalRule) allowedIn() string {
+	var places []string
+	for _, l := range e.Layers {
+		places = append(places, "the "+l+" layer")
+	}
+	places = append(places, e.Paths...)
+	if len(places) == 0 {
+		return "no package"
+	}
+	return strings.Join(places, ", ")
+}
+
+// requiredModule returns the longest module of requires that provides
+// importPath, or "".
+func requiredModule(requires []string, importPath string) string {
+	module := ""
+	for _, req := range requires {
+		if inTree(req, importPath) && len(req) > len(module) {
+			module = req
+		}
+	}
+	return module
+}
+
+// readRequires returns the module paths the go.mod at p requires, in both
+// the single-line and the block form.
+func readRequires(p string) ([]string, error) {
+	return readDirective(p, "require")
+}
+
+// readDirective returns the first argument of every entry of directive in
+// the go.mod or go.work file at p, in both the single-line and the block
+// form.
+func readDirective(p, directive string) ([]string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(p), err)
+	}
+	defer f.Close()
+
+	var args []string
+	inBlock := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case inBlock:
+		case strings.HasPrefix(line, directive) && strings.TrimSpace(strings.TrimPrefix(line, directive)) == "(":
+			inBlock = true
+			continue
+		default:
+			rest, ok := strings.CutPrefix(line, directive)
+			if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+				continue
+			}
+			line = strings.TrimSpace(rest)
+		}
+		// Quoted arguments may contain spaces.
+		if quoted, err := strconv.QuotedPrefix(line); err == nil {
+			arg, _ := strconv.Unquote(quoted)
+			args = append(args, arg)
+		} else if fields := strings.Fields(line); len(fields) > 0 {
+			args = append(args, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(p), err)
+	}
+	return args, nil
+}
+
+// moduleRequires reads the requirements of the module graph was loaded from.
+func moduleRequires(graph *importGraph) ([]string, error) {
+	return readRequires(filepath.Join(graph.root, "go.mod"))
+}
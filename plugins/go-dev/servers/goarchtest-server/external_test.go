@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadDirective(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		directive string
+		want      []string
+	}{
+		{
+			name:      "single-line requires",
+			file:      "module ex\n\ngo 1.22\n\nrequire github.com/google/uuid v1.6.0\nrequire\tgolang.org/x/sync v0.7.0\n",
+			directive: "require",
+			want:      []string{"github.com/google/uuid", "golang.org/x/sync"},
+		},
+		{
+			name: "require block with comments",
+			file: `module ex
+
+require (
+	// Identifiers.
+	github.com/google/uuid v1.6.0
+	golang.org/x/sync v0.7.0 // indirect
+)
+`,
+			directive: "require",
+			want:      []string{"github.com/google/uuid", "golang.org/x/sync"},
+		},
+		{
+			name: "quoted paths",
+			file: `module ex
+
+require "github.com/google/uuid" v1.6.0
+`,
+			directive: "require",
+			want:      []string{"github.com/google/uuid"},
+		},
+		{
+			name: "other directives are ignored",
+			file: `module ex
+
+require github.com/google/uuid v1.6.0
+
+replace (
+	github.com/google/uuid => ../uuid
+)
+
+exclude golang.org/x/sync v0.6.0
+// require github.com/commented/out v1.0.0
+`,
+			directive: "require",
+			want:      []string{"github.com/google/uuid"},
+		},
+		{
+			name: "use directives of a go.work",
+			file: `go 1.22
+
+use ./billing
+use (
+	./order // the core
+	"./shared kernel"
+)
+`,
+			directive: "use",
+			want:      []string{"./billing", "./order", "./shared kernel"},
+		},
+		{
+			name:      "no entries",
+			file:      "module ex\n\ngo 1.22\n",
+			directive: "require",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "go.mod")
+			if err := os.WriteFile(p, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readDirective(p, tt.directive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("readDirective(%q) = %q, want %q", tt.directive, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadDirectiveMissingFile(t *testing.T) {
+	if _, err := readDirective(filepath.Join(t.TempDir(), "go.mod"), "require"); err == nil {
+		t.Error("readDirective of a missing file succeeded")
+	}
+}
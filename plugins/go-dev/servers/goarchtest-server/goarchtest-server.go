@@ -4,16 +4,36 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+const (
+	serverName    = "goarchtest-analyzer"
+	serverVersion = "1.0.0"
+)
+
 type GoArchTestServer struct {
 	projectRoot string
 	mcpServer   *server.MCPServer
+	cache       *graphCache
+
+	// workspace is the go.work workspace rooted at projectRoot, whose
+	// modules are analyzed by servers of their own, or nil.
+	workspace *workspace
+	// module is the workspace module at projectRoot when a go.work uses
+	// it, or nil.
+	module *workspaceModule
+
+	mu            sync.RWMutex
+	rules         *archRules
+	rulesProblems []string
 }
 
 func NewGoArchTestServer(projectRoot string) *GoArchTestServer {
@@ -23,16 +43,39 @@ func NewGoArchTestServer(projectRoot string) *GoArchTestServer {
 
 	s := &GoArchTestServer{
 		projectRoot: projectRoot,
+		cache:       newGraphCache(projectRoot),
+	}
+	ws, err := loadWorkspace(projectRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Workspace error: %v\n", err)
+	}
+	s.workspace = ws
+	if ws == nil {
+		s.module = workspaceModuleAt(projectRoot)
 	}
 
 	mcpServer := server.NewMCPServer(
-		"goarchtest-analyzer",
-		"1.0.0",
+		serverName,
+		serverVersion,
 		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(false),
 	)
 
 	s.mcpServer = mcpServer
+	if ws != nil {
+		for _, m := range ws.modules {
+			if err := newModuleServer(m).reloadRules(); err != nil {
+				fmt.Fprintf(os.Stderr, "Rules error in %s: %v\n", m.Dir, err)
+			}
+		}
+	} else if err := s.reloadRules(); err != nil {
+		fmt.Fprintf(os.Stderr, "Rules error: %v\n", err)
+	}
+
 	s.setupHandlers()
+	s.setupResources()
+	s.setupPrompts()
 
 	return s
 }
@@ -44,15 +87,16 @@ func (s *GoArchTestServer) setupHandlers() {
 			mcp.WithDescription("Check if a layer has illegal dependencies on other layers"),
 			mcp.WithString("layer",
 				mcp.Required(),
-				mcp.Description("Layer to check (domain, application, infrastructure)"),
-				mcp.Enum("domain", "application", "infrastructure"),
+				mcp.Description("Layer to check, as declared in the rules file (default layers: domain, application, infrastructure)"),
 			),
 			mcp.WithString("domain",
-				mcp.Required(),
-				mcp.Description("Domain/bounded context to check (e.g., 'user', 'order')"),
+				mcp.Description("Domain/bounded context to check (e.g., 'user', 'order'). Omit to check the layer in every domain"),
 			),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
 		),
-		s.checkLayerDependencies,
+		s.perModule((*GoArchTestServer).checkLayerDependencies),
 	)
 
 	s.mcpServer.AddTool(
@@ -66,38 +110,295 @@ func (s *GoArchTestServer) setupHandlers() {
 				mcp.Required(),
 				mcp.Description("Target domain that should not be imported"),
 			),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
 		),
-		s.checkDomainIsolation,
+		s.perModule((*GoArchTestServer).checkDomainIsolation),
 	)
 
 	s.mcpServer.AddTool(
 		mcp.NewTool("check_naming_conventions",
-			mcp.WithDescription("Validate naming conventions for repositories, use cases, handlers"),
+			mcp.WithDescription("Check type declarations against the naming rules: name suffixes, interface or struct kind, required methods such as Execute, and New<Type> constructors"),
 			mcp.WithString("pattern",
-				mcp.Required(),
-				mcp.Description("Pattern to check (repository, usecase, handler)"),
-				mcp.Enum("repository", "usecase", "handler"),
+				mcp.Description("Naming rule to check, as declared in the rules file (default rules: repository, usecase, handler). Omit to check every rule"),
 			),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
 		),
-		s.checkNamingConventions,
+		s.perModule((*GoArchTestServer).checkNamingConventions),
 	)
 
 	s.mcpServer.AddTool(
 		mcp.NewTool("run_all_architecture_tests",
-			mcp.WithDescription("Run all architecture tests defined in test/architecture"),
+			mcp.WithDescription("Run all architecture tests defined in test/architecture, or sweep the whole project with the server's own rule set"),
+			mcp.WithString("mode",
+				mcp.Description("tests: run test/architecture only (default); sweep: run every built-in rule across all domains, merged with test/architecture when it exists"),
+				mcp.Enum("tests", "sweep"),
+			),
+			mcp.WithString("run",
+				mcp.Description("Optional: only run tests matching this regular expression (go test -run)"),
+			),
+			mcp.WithString("package",
+				mcp.Description("Optional: package pattern to test instead of ./test/architecture/..."),
+			),
+			mcp.WithNumber("timeout",
+				mcp.Description("Optional: seconds before the test run is stopped (default: 300)"),
+			),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
 		),
-		s.runAllArchitectureTests,
+		s.perModule((*GoArchTestServer).runAllArchitectureTests),
 	)
 
 	s.mcpServer.AddTool(
 		mcp.NewTool("generate_dependency_graph",
-			mcp.WithDescription("Generate a dependency graph visualization"),
+			mcp.WithDescription("Generate a domain/layer dependency graph with illegal edges highlighted"),
 			mcp.WithString("domain",
 				mcp.Description("Optional: Specific domain to visualize"),
 			),
+			mcp.WithString("format",
+				mcp.Description("Output format: Graphviz DOT, Mermaid flowchart or JSON nodes/edges (default: dot)"),
+				mcp.Enum("dot", "mermaid", "json"),
+			),
+			mcp.WithBoolean("write",
+				mcp.Description("Also save the graph to architecture-graph.<dot|mmd|json> in the project root"),
+			),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).generateDependencyGraph),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("detect_cycles",
+			mcp.WithDescription("Detect import cycles between packages and between bounded contexts, including cycles through shared helper packages"),
+			mcp.WithString("level",
+				mcp.Description("Graph to analyze: package, domain or all (default: all)"),
+				mcp.Enum("package", "domain", "all"),
+			),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).detectCycles),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("check_domain_purity",
+			mcp.WithDescription("Check that the pure layers (default: domain) import only allowed standard library packages and no third-party or outer-layer packages"),
+			mcp.WithString("domain",
+				mcp.Description("Optional: Domain/bounded context to check. Omit to check every domain"),
+			),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
 		),
-		s.generateDependencyGraph,
+		s.perModule((*GoArchTestServer).checkDomainPurity),
 	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("check_external_dependencies",
+			mcp.WithDescription("Check imports of third-party modules against the external module policy of the rules file, which maps module path patterns to the layers allowed to import them"),
+			mcp.WithString("domain",
+				mcp.Description("Optional: Domain/bounded context to check. Omit to check every domain"),
+			),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).checkExternalDependencies),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("check_module_dependencies",
+			mcp.WithDescription("Check imports between the modules of a go.work workspace against the module rules of the rules file, e.g. that service modules only import the shared contracts module"),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).checkModuleDependencies),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("check_port_implementations",
+			mcp.WithDescription("Check that every port (interface of the domain layer) has an adapter in the domain's infrastructure layer, that adapters implement a port, and that use cases depend on ports rather than concrete adapters"),
+			mcp.WithString("domain",
+				mcp.Description("Optional: Domain/bounded context to check. Omit to check every domain"),
+			),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).checkPortImplementations),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("check_changed_packages",
+			mcp.WithDescription("Run the layer, isolation, naming, purity, external and workspace module rules only on packages changed since a git base ref and their direct importers"),
+			mcp.WithString("base",
+				mcp.Description("Git ref to diff against (default: merge-base of HEAD with main)"),
+			),
+			baselineOption(),
+			reportOption(),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).checkChangedPackages),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("create_baseline",
+			mcp.WithDescription("Record all current violations in "+baselineFileName+" so checks only report new ones"),
+			mcp.WithBoolean("pruneOnly",
+				mcp.Description("Only remove entries that have been fixed; never add new violations (default: false)"),
+			),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).createBaseline),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("list_suppressions",
+			mcp.WithDescription("List every "+ignoreDirective+" directive with its reason and the violations it suppresses, and flag invalid directives and ones that no longer suppress anything"),
+			mcp.WithString("rule",
+				mcp.Description("Optional: only list directives that suppress this rule id, e.g. layer-deps"),
+			),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).listSuppressions),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("list_architecture",
+			mcp.WithDescription("List every bounded context, its layers and packages, and internal packages that fit no layer"),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).listArchitecture),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("package_metrics",
+			mcp.WithDescription("Compute afferent/efferent coupling, instability, abstractness and distance from the main sequence for every package, domain and layer, and rank the worst offenders"),
+			mcp.WithString("domain",
+				mcp.Description("Optional: only measure the packages of this domain"),
+			),
+			mcp.WithNumber("top",
+				mcp.Description("Number of packages farthest from the main sequence to rank (default: 10, 0 for all)"),
+			),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).packageMetrics),
+	)
+
+	s.mcpServer.AddTool(
+		mcp.NewTool("validate_rules_config",
+			mcp.WithDescription("Validate the project's .goarch.yaml rules file and reload it"),
+			moduleOption(),
+		),
+		s.perModule((*GoArchTestServer).validateRulesConfig),
+	)
+}
+
+// baselineOption is the switch every check accepts to ignore the baseline.
+func baselineOption() mcp.ToolOption {
+	return mcp.WithBoolean("baseline",
+		mcp.Description("Only report violations missing from "+baselineFileName+" when it exists (default: true)"),
+	)
+}
+
+// reportOption lets a check also render its violations for CI tooling.
+func reportOption() mcp.ToolOption {
+	return mcp.WithString("report",
+		mcp.Description("Optional: also return the violations as a sarif (SARIF 2.1.0) or junit (JUnit XML) document"),
+		mcp.Enum("sarif", "junit"),
+	)
+}
+
+// withReport appends the report document requested by the report argument
+// to result as a second text content. Checks narrowed to one domain only
+// report on that domain.
+func withReport(request mcp.CallToolRequest, result *mcp.CallToolResult, report checkReport, graph *importGraph, rules *archRules) *mcp.CallToolResult {
+	format := request.GetString("report", "")
+	if format == "" {
+		return result
+	}
+	domains := discoveredDomains(graph, rules)
+	for _, arg := range []string{"domain", "sourceDomain"} {
+		if d := request.GetString(arg, ""); d != "" {
+			domains = []string{d}
+		}
+	}
+	data, err := renderReport(format, report, graph, rules, domains)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error rendering %s report: %v", format, err))
+	}
+	result.Content = append(result.Content, mcp.NewTextContent(string(data)))
+	return result
+}
+
+// renderReport encodes report in one of the CI report formats. domains are
+// the bounded contexts the check covered.
+func renderReport(format string, report checkReport, graph *importGraph, rules *archRules, domains []string) ([]byte, error) {
+	switch format {
+	case "sarif":
+		return sarifReport(report, graph.packageFile)
+	case "junit":
+		return junitReport(report, func(importPath string) string {
+			rel, _ := graph.relImport(importPath)
+			domain, _ := rules.classify(rel)
+			return domain
+		}, domains)
+	}
+	return nil, fmt.Errorf("unsupported report format %q", format)
+}
+
+// reloadRules reads the project's rules file, or for a workspace module
+// the rules it shares with the workspace. An invalid file leaves the server
+// without rules so that checks fail instead of silently falling back to the
+// defaults.
+func (s *GoArchTestServer) reloadRules() error {
+	var rules *archRules
+	var problems []string
+	var err error
+	if s.module != nil {
+		rules, problems, err = loadModuleRules(s.projectRoot, s.module.workspace)
+	} else {
+		rules, problems, err = loadRules(s.projectRoot)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules, s.rulesProblems = rules, problems
+
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid rules file:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// analyze returns the cached import graph, refreshed for any files changed
+// since the last call, together with the active rules.
+func (s *GoArchTestServer) analyze() (*importGraph, *archRules, error) {
+	s.mu.RLock()
+	rules, problems := s.rules, s.rulesProblems
+	s.mu.RUnlock()
+
+	if rules == nil {
+		if len(problems) > 0 {
+			return nil, nil, fmt.Errorf("invalid rules file, run validate_rules_config for details:\n  - %s", strings.Join(problems, "\n  - "))
+		}
+		return nil, nil, fmt.Errorf("rules file could not be read")
+	}
+
+	graph, _, err := s.cache.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return graph, rules, nil
 }
 
 func (s *GoArchTestServer) checkLayerDependencies(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -106,25 +407,34 @@ func (s *GoArchTestServer) checkLayerDependencies(ctx context.Context, request m
 		return mcp.NewToolResultError("layer parameter is required"), nil
 	}
 
-	domain, err := request.RequireString("domain")
+	domain := request.GetString("domain", "")
+
+	graph, rules, err := s.analyze()
 	if err != nil {
-		return mcp.NewToolResultError("domain parameter is required"), nil
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
 	}
 
-	testCode := s.generateLayerTest(layer, domain)
-	result, err := s.runGoTest(testCode)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error running test: %v", err)), nil
+	if _, ok := rules.layer(layer); !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("unknown layer %q, expected one of: %s", layer, strings.Join(rules.layerNames(), ", "))), nil
+	}
+
+	violations := append(layerViolations(graph, rules, layer, domain), protectedViolations(graph, rules, layer, domain)...)
+	report := newCheckReport("check_layer_dependencies", violations)
+	if err := s.filterReport(request, graph, rules, &report); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
+	}
+	if domain == "" {
+		domain = "all domains"
 	}
 
 	var message string
-	if result.success {
+	if len(report.Violations) == 0 {
 		message = fmt.Sprintf("✅ %s layer in %s has no illegal dependencies", layer, domain)
 	} else {
-		message = fmt.Sprintf("❌ %s layer violations found:\n%s", layer, result.output)
+		message = fmt.Sprintf("❌ %s layer violations found:\n%s", layer, formatViolations(report.Violations))
 	}
 
-	return mcp.NewToolResultText(message), nil
+	return withReport(request, mcp.NewToolResultStructured(report, message+report.filterNote()), report, graph, rules), nil
 }
 
 func (s *GoArchTestServer) checkDomainIsolation(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -138,188 +448,613 @@ func (s *GoArchTestServer) checkDomainIsolation(ctx context.Context, request mcp
 		return mcp.NewToolResultError("targetDomain parameter is required"), nil
 	}
 
-	testCode := s.generateDomainIsolationTest(sourceDomain, targetDomain)
-	result, err := s.runGoTest(testCode)
+	graph, rules, err := s.analyze()
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error running test: %v", err)), nil
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+
+	report := newCheckReport("check_domain_isolation", domainIsolationViolations(graph, rules, sourceDomain, targetDomain))
+	if err := s.filterReport(request, graph, rules, &report); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
 	}
 
 	var message string
-	if result.success {
+	if len(report.Violations) == 0 {
 		message = fmt.Sprintf("✅ %s domain is properly isolated from %s", sourceDomain, targetDomain)
 	} else {
-		message = fmt.Sprintf("❌ Domain isolation violation:\n%s", result.output)
+		message = fmt.Sprintf("❌ Domain isolation violation:\n%s", formatViolations(report.Violations))
 	}
 
-	return mcp.NewToolResultText(message), nil
+	return withReport(request, mcp.NewToolResultStructured(report, message+report.filterNote()), report, graph, rules), nil
 }
 
 func (s *GoArchTestServer) checkNamingConventions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	pattern, err := request.RequireString("pattern")
+	pattern := request.GetString("pattern", "")
+
+	graph, rules, err := s.analyze()
 	if err != nil {
-		return mcp.NewToolResultError("pattern parameter is required"), nil
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
 	}
 
-	testCode := s.generateNamingTest(pattern)
-	result, err := s.runGoTest(testCode)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error running test: %v", err)), nil
+	checked := rules.Naming
+	if pattern != "" {
+		rule, ok := rules.namingRule(pattern)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown naming rule %q, expected one of: %s", pattern, strings.Join(rules.namingRuleNames(), ", "))), nil
+		}
+		checked = []namingRule{rule}
+	}
+
+	var violations []violation
+	for _, rule := range checked {
+		violations = append(violations, namingViolations(graph, rules, rule)...)
+	}
+	report := newCheckReport("check_naming_conventions", violations)
+	if err := s.filterReport(request, graph, rules, &report); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
+	}
+	if pattern == "" {
+		pattern = strings.Join(rules.namingRuleNames(), ", ")
 	}
 
 	var message string
-	if result.success {
+	if len(report.Violations) == 0 {
 		message = fmt.Sprintf("✅ %s naming conventions followed", pattern)
 	} else {
-		message = fmt.Sprintf("❌ Naming convention violations:\n%s", result.output)
+		message = fmt.Sprintf("❌ Naming convention violations:\n%s", formatViolations(report.Violations))
 	}
 
-	return mcp.NewToolResultText(message), nil
+	return withReport(request, mcp.NewToolResultStructured(report, message+report.filterNote()), report, graph, rules), nil
 }
 
 func (s *GoArchTestServer) runAllArchitectureTests(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	cmd := exec.Command("go", "test", "./test/architecture/...", "-v")
-	cmd.Dir = s.projectRoot
-	output, err := cmd.CombinedOutput()
+	opts := testOptions{
+		run:     request.GetString("run", ""),
+		pkg:     request.GetString("package", ""),
+		timeout: time.Duration(request.GetFloat("timeout", 0) * float64(time.Second)),
+	}
 
+	if request.GetString("mode", "tests") == "sweep" {
+		return s.sweepArchitecture(ctx, request, opts)
+	}
+
+	run, err := s.runArchitectureTests(ctx, opts)
 	if err != nil {
-		message := fmt.Sprintf("❌ Architecture tests failed:\n\n%s", string(output))
-		return mcp.NewToolResultText(message), nil
+		return mcp.NewToolResultError(fmt.Sprintf("Error running tests: %v", err)), nil
+	}
+	if !run.Ran {
+		return mcp.NewToolResultError(fmt.Sprintf("No %s directory found; use mode \"sweep\" to run the built-in rules", architectureTestsDir)), nil
 	}
 
-	message := fmt.Sprintf("✅ All architecture tests passed\n\n%s", string(output))
-	return mcp.NewToolResultText(message), nil
+	result := struct {
+		Check string `json:"check"`
+		testRun
+	}{"run_all_architecture_tests", run}
+
+	switch {
+	case run.TimedOut:
+		message := fmt.Sprintf("❌ Architecture tests timed out (%s)\n\n%s", run.summary(), run.failures())
+		return mcp.NewToolResultStructured(result, message), nil
+	case !run.Passed:
+		message := fmt.Sprintf("❌ Architecture tests failed (%s):\n\n%s", run.summary(), run.failures())
+		return mcp.NewToolResultStructured(result, message), nil
+	}
+
+	message := fmt.Sprintf("✅ All architecture tests passed (%s)", run.summary())
+	return mcp.NewToolResultStructured(result, message), nil
 }
 
-func (s *GoArchTestServer) generateDependencyGraph(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	graphPath := filepath.Join(s.projectRoot, "architecture-graph.dot")
-	message := fmt.Sprintf("Dependency graph generation would require goarchtest library integration.\nGraph would be saved to: %s", graphPath)
-	return mcp.NewToolResultText(message), nil
+// sweepArchitecture runs every built-in rule over the whole project and merges
+// the outcome with the user-defined tests into one report.
+func (s *GoArchTestServer) sweepArchitecture(ctx context.Context, request mcp.CallToolRequest, opts testOptions) (*mcp.CallToolResult, error) {
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+
+	run, err := s.runArchitectureTests(ctx, opts)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error running tests: %v", err)), nil
+	}
+
+	domains, violations := runAllRules(graph, rules)
+	report := sweepReport{
+		checkReport: newCheckReport("run_all_architecture_tests", violations),
+		Domains:     append([]string{}, domains...),
+		Tests:       run,
+	}
+	if err := s.filterReport(request, graph, rules, &report.checkReport); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
+	}
+	if run.Ran && !run.Passed {
+		report.Passed = false
+	}
+
+	var b strings.Builder
+	if report.Passed {
+		b.WriteString("✅ Architecture sweep passed")
+	} else {
+		b.WriteString("❌ Architecture sweep failed")
+	}
+	fmt.Fprintf(&b, "\nDomains: %s\nRules: %s\n", strings.Join(domains, ", "), rules.describe())
+
+	if len(report.Violations) == 0 {
+		b.WriteString("\nNo rule violations\n")
+	} else {
+		fmt.Fprintf(&b, "\n%d rule violation(s):\n%s\n", report.Summary.Total, formatViolations(report.Violations))
+	}
+
+	switch {
+	case !run.Ran:
+		fmt.Fprintf(&b, "\nNo %s directory, user-defined tests skipped", architectureTestsDir)
+	case run.TimedOut:
+		fmt.Fprintf(&b, "\n❌ User-defined tests timed out (%s)\n\n%s", run.summary(), run.failures())
+	case run.Passed:
+		fmt.Fprintf(&b, "\n✅ User-defined tests passed (%s)", run.summary())
+	default:
+		fmt.Fprintf(&b, "\n❌ User-defined tests failed (%s):\n\n%s", run.summary(), run.failures())
+	}
+
+	return withReport(request, mcp.NewToolResultStructured(report, b.String()+report.filterNote()), report.checkReport, graph, rules), nil
 }
 
-func (s *GoArchTestServer) generateLayerTest(layer, domain string) string {
-	var forbidden []string
-	switch layer {
-	case "domain":
-		forbidden = []string{"application", "infrastructure"}
-	case "application":
-		forbidden = []string{"infrastructure"}
+func (s *GoArchTestServer) generateDependencyGraph(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	domain := request.GetString("domain", "")
+	format := request.GetString("format", "dot")
+	write := request.GetBool("write", false)
+
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+
+	lg := buildLayerGraph(graph, rules, domain)
+
+	var rendered, ext string
+	switch format {
+	case "dot":
+		rendered, ext = lg.dot(), "dot"
+	case "mermaid":
+		rendered, ext = lg.mermaid(), "mmd"
+	case "json":
+		rendered, err = lg.json()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error encoding graph: %v", err)), nil
+		}
+		ext = "json"
 	default:
-		return ""
+		return mcp.NewToolResultError(fmt.Sprintf("unsupported format %q", format)), nil
 	}
 
-	if len(forbidden) == 0 {
-		return ""
+	summary := fmt.Sprintf("%d nodes, %d edges, %d illegal", len(lg.Nodes), len(lg.Edges), lg.illegalEdges())
+	if write {
+		graphPath := filepath.Join(s.projectRoot, "architecture-graph."+ext)
+		if err := os.WriteFile(graphPath, []byte(rendered), 0o644); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error writing graph: %v", err)), nil
+		}
+		summary += fmt.Sprintf("\nGraph saved to: %s", graphPath)
 	}
 
-	return fmt.Sprintf(`
-package test
-import (
-  "testing"
-  "path/filepath"
-  "github.com/solrac97gr/goarchtest"
-  "github.com/stretchr/testify/assert"
-)
-func TestLayerDependencies(t *testing.T) {
-  projectPath, _ := filepath.Abs(".")
-  result := goarchtest.InPath(projectPath).
-    That().
-    ResideInNamespace("internal/%s/%s").
-    ShouldNot().
-    HaveDependencyOn("internal/%s/%s").
-    GetResult()
-  assert.True(t, result.IsSuccessful)
+	return mcp.NewToolResultStructured(lg, fmt.Sprintf("%s\n\n%s", summary, rendered)), nil
 }
-`, domain, layer, domain, forbidden[0])
+
+func (s *GoArchTestServer) detectCycles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	level := request.GetString("level", "all")
+
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+
+	cycles := detectCycles(graph, rules, level)
+	violations := cycleViolations(graph, cycles)
+	report := newCheckReport("detect_cycles", slices.Clone(violations))
+	if err := s.filterReport(request, graph, rules, &report); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
+	}
+
+	remaining := survivingCycles(cycles, violations, report.Violations)
+
+	result := struct {
+		checkReport
+		Cycles []importCycle `json:"cycles"`
+	}{report, append([]importCycle{}, remaining...)}
+
+	if len(remaining) == 0 {
+		return withReport(request, mcp.NewToolResultStructured(result, "✅ No import cycles found"+report.filterNote()), report, graph, rules), nil
+	}
+
+	message := fmt.Sprintf("❌ %d import cycle(s) found:\n%s", len(remaining), formatCycles(remaining))
+	return withReport(request, mcp.NewToolResultStructured(result, message+report.filterNote()), report, graph, rules), nil
 }
 
-func (s *GoArchTestServer) generateDomainIsolationTest(sourceDomain, targetDomain string) string {
-	return fmt.Sprintf(`
-package test
-import (
-  "testing"
-  "path/filepath"
-  "github.com/solrac97gr/goarchtest"
-  "github.com/stretchr/testify/assert"
-)
-func TestDomainIsolation(t *testing.T) {
-  projectPath, _ := filepath.Abs(".")
-  result := goarchtest.InPath(projectPath).
-    That().
-    ResideInNamespace("internal/%s/").
-    ShouldNot().
-    HaveDependencyOn("internal/%s/").
-    GetResult()
-  assert.True(t, result.IsSuccessful)
+func (s *GoArchTestServer) checkDomainPurity(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	domain := request.GetString("domain", "")
+
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+
+	report := newCheckReport("check_domain_purity", domainPurityViolations(graph, rules, domain))
+	if err := s.filterReport(request, graph, rules, &report); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
+	}
+	layers := strings.Join(rules.pureLayers(), ", ")
+	if domain == "" {
+		domain = "all domains"
+	}
+
+	var message string
+	if len(report.Violations) == 0 {
+		message = fmt.Sprintf("✅ %s layer in %s is pure", layers, domain)
+	} else {
+		message = fmt.Sprintf("❌ %s layer purity violations found:\n%s", layers, formatViolations(report.Violations))
+	}
+
+	return withReport(request, mcp.NewToolResultStructured(report, message+report.filterNote()), report, graph, rules), nil
 }
-`, sourceDomain, targetDomain)
+
+func (s *GoArchTestServer) checkExternalDependencies(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	domain := request.GetString("domain", "")
+
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+	requires, err := moduleRequires(graph)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error reading requirements: %v", err)), nil
+	}
+
+	var violations []violation
+	for _, v := range externalViolations(graph, rules) {
+		rel, _ := graph.relImport(v.Package)
+		if d, _ := rules.classify(rel); domain == "" || d == domain {
+			violations = append(violations, v)
+		}
+	}
+	report := newCheckReport("check_external_dependencies", violations)
+	if err := s.filterReport(request, graph, rules, &report); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
+	}
+
+	result := struct {
+		checkReport
+		Policies []externalPolicy `json:"policies"`
+	}{report, externalPolicies(rules, requires)}
+
+	if len(rules.External) == 0 {
+		message := "✅ No external module policy declared; add an external section to " + rulesFileNames[0] + " to restrict third-party modules to layers"
+		return withReport(request, mcp.NewToolResultStructured(result, message), report, graph, rules), nil
+	}
+	if domain == "" {
+		domain = "all domains"
+	}
+
+	var message string
+	if len(report.Violations) == 0 {
+		message = fmt.Sprintf("✅ Third-party imports in %s follow the external module policy (%d rule(s))", domain, len(rules.External))
+	} else {
+		message = fmt.Sprintf("❌ External module policy violations found:\n%s", formatViolations(report.Violations))
+	}
+
+	return withReport(request, mcp.NewToolResultStructured(result, message+report.filterNote()), report, graph, rules), nil
 }
 
-func (s *GoArchTestServer) generateNamingTest(pattern string) string {
-	configs := map[string]struct {
-		suffix    string
-		namespace string
-	}{
-		"repository": {suffix: "Repository", namespace: "internal/*/domain"},
-		"usecase":    {suffix: "UseCase", namespace: "internal/*/application/usecase"},
-		"handler":    {suffix: "Handler", namespace: "internal/*/infrastructure/http"},
+func (s *GoArchTestServer) checkModuleDependencies(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
 	}
 
-	config, ok := configs[pattern]
-	if !ok {
-		return ""
+	report := newCheckReport("check_module_dependencies", moduleViolations(graph, rules))
+	if err := s.filterReport(request, graph, rules, &report); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
 	}
 
-	return fmt.Sprintf(`
-package test
-import (
-  "testing"
-  "path/filepath"
-  "github.com/solrac97gr/goarchtest"
-  "github.com/stretchr/testify/assert"
-)
-func TestNaming(t *testing.T) {
-  projectPath, _ := filepath.Abs(".")
-  result := goarchtest.InPath(projectPath).
-    That().
-    ResideInNamespace("%s").
-    Should().
-    HaveNameEndingWith("%s").
-    GetResult()
-  assert.True(t, result.IsSuccessful)
+	imports := workspaceImports(graph, rules)
+	result := struct {
+		checkReport
+		Module  string   `json:"module"`
+		Imports []string `json:"imports"`
+	}{report, graph.modulePath, imports}
+
+	if len(rules.workspace) == 0 {
+		message := fmt.Sprintf("✅ %s is not part of a %s workspace, so no module rule applies", graph.modulePath, workspaceFileName)
+		return withReport(request, mcp.NewToolResultStructured(result, message), report, graph, rules), nil
+	}
+	summary := "Imports no other workspace module"
+	if len(imports) > 0 {
+		summary = "Imports workspace modules: " + strings.Join(imports, ", ")
+	}
+	if _, ok := rules.moduleRule(graph.modulePath); !ok {
+		message := fmt.Sprintf("✅ No module rule covers %s; add a modules section to the workspace's %s to restrict its imports\n%s", graph.modulePath, rulesFileNames[0], summary)
+		return withReport(request, mcp.NewToolResultStructured(result, message), report, graph, rules), nil
+	}
+
+	var message string
+	if len(report.Violations) == 0 {
+		message = fmt.Sprintf("✅ %s follows its module rule\n%s", graph.modulePath, summary)
+	} else {
+		message = fmt.Sprintf("❌ Module rule violations found:\n%s\n\n%s", formatViolations(report.Violations), summary)
+	}
+
+	return withReport(request, mcp.NewToolResultStructured(result, message+report.filterNote()), report, graph, rules), nil
+}
+
+func (s *GoArchTestServer) checkPortImplementations(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	domain := request.GetString("domain", "")
+
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+
+	ports, violations := portViolations(graph, rules, domain)
+	report := newCheckReport("check_port_implementations", violations)
+	if err := s.filterReport(request, graph, rules, &report); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
+	}
+
+	result := struct {
+		checkReport
+		Ports []portInfo `json:"ports"`
+	}{report, ports}
+
+	implemented := 0
+	for _, p := range ports {
+		if len(p.Adapters) > 0 {
+			implemented++
+		}
+	}
+	if domain == "" {
+		domain = "all domains"
+	}
+	summary := fmt.Sprintf("%d port(s) in %s, %d with an adapter", len(ports), domain, implemented)
+
+	var message string
+	if len(report.Violations) == 0 {
+		message = fmt.Sprintf("✅ Ports and adapters fit together\n%s", summary)
+	} else {
+		message = fmt.Sprintf("❌ Port/adapter violations found:\n%s\n\n%s", formatViolations(report.Violations), summary)
+	}
+
+	return withReport(request, mcp.NewToolResultStructured(result, message+report.filterNote()), report, graph, rules), nil
+}
+
+func (s *GoArchTestServer) checkChangedPackages(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	base, err := s.resolveBase(ctx, request.GetString("base", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error resolving base ref: %v", err)), nil
+	}
+
+	files, err := s.changedGoFiles(ctx, base)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error listing changed files: %v", err)), nil
+	}
+
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+
+	touched, affected := affectedPackages(graph, files)
+	report := newCheckReport("check_changed_packages", scopedViolations(graph, rules, affected))
+	if err := s.filterReport(request, graph, rules, &report); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error applying baseline: %v", err)), nil
+	}
+
+	result := struct {
+		checkReport
+		Base         string   `json:"base"`
+		ChangedFiles []string `json:"changedFiles"`
+		Touched      []string `json:"touchedPackages"`
+		Checked      []string `json:"checkedPackages"`
+	}{report, base, append([]string{}, files...), append([]string{}, touched...), append([]string{}, affected...)}
+
+	scope := fmt.Sprintf("%d changed Go file(s) since %.12s, %d package(s) checked (%d touched + direct importers)",
+		len(files), base, len(affected), len(touched))
+
+	var message string
+	if len(report.Violations) == 0 {
+		message = fmt.Sprintf("✅ No architecture violations in changed packages\n%s", scope)
+	} else {
+		message = fmt.Sprintf("❌ Architecture violations in changed packages:\n%s\n\n%s", formatViolations(report.Violations), scope)
+	}
+
+	return withReport(request, mcp.NewToolResultStructured(result, message+report.filterNote()), report, graph, rules), nil
+}
+
+func (s *GoArchTestServer) createBaseline(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pruneOnly := request.GetBool("pruneOnly", false)
+
+	recorded, removed, err := s.recordBaseline(pruneOnly)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error creating baseline: %v", err)), nil
+	}
+
+	result := map[string]any{
+		"check":    "create_baseline",
+		"file":     baselineFileName,
+		"recorded": recorded,
+		"removed":  removed,
+	}
+	return mcp.NewToolResultStructured(result, "✅ "+baselineMessage(recorded, removed, pruneOnly)), nil
 }
-`, config.namespace, config.suffix)
+
+func (s *GoArchTestServer) listSuppressions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+
+	report := listSuppressions(graph, rules, request.GetString("rule", ""))
+	return mcp.NewToolResultStructured(report, report.String()), nil
 }
 
-type testResult struct {
-	success bool
-	output  string
+func (s *GoArchTestServer) listArchitecture(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
+	}
+
+	arch := discoverArchitecture(graph, rules)
+	return mcp.NewToolResultStructured(arch, arch.String()), nil
 }
 
-func (s *GoArchTestServer) runGoTest(testCode string) (*testResult, error) {
-	if testCode == "" {
-		return &testResult{success: true, output: "No test needed"}, nil
+func (s *GoArchTestServer) packageMetrics(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	domain := request.GetString("domain", "")
+	top := int(request.GetFloat("top", 10))
+
+	graph, rules, err := s.analyze()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error loading packages: %v", err)), nil
 	}
 
-	// In a real implementation, write test to temp file and run it
-	// For now, return mock result
-	return &testResult{
-		success: true,
-		output:  "Test executed successfully",
-	}, nil
+	report := packageMetrics(graph, rules, domain, top)
+	return mcp.NewToolResultStructured(report, report.String()), nil
 }
 
-func main() {
-	projectRoot := ""
-	if len(os.Args) > 1 {
-		projectRoot = os.Args[1]
+func (s *GoArchTestServer) validateRulesConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	err := s.reloadRules()
+
+	s.mu.RLock()
+	rules, problems := s.rules, s.rulesProblems
+	s.mu.RUnlock()
+
+	result := map[string]any{
+		"check":    "validate_rules_config",
+		"valid":    err == nil,
+		"problems": append([]string{}, problems...),
+	}
+	if err != nil {
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("❌ %v", err)), nil
 	}
 
-	s := NewGoArchTestServer(projectRoot)
-	
-	fmt.Fprintln(os.Stderr, "GoArchTest MCP server running")
-	
-	if err := server.ServeStdio(s.mcpServer); err != nil {
-		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
-		os.Exit(1)
+	result["source"] = rules.describe()
+	result["layers"] = rules.layerNames()
+	result["namingRules"] = rules.namingRuleNames()
+
+	if rules.source == "" {
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("✅ No %s found, using built-in defaults (layers: %s)", rulesFileNames[0], strings.Join(rules.layerNames(), ", "))), nil
 	}
+
+	message := fmt.Sprintf("✅ %s is valid and loaded\nLayers: %s\nNaming rules: %s\nIsolation exceptions: %d",
+		rules.source,
+		strings.Join(rules.layerNames(), ", "),
+		strings.Join(rules.namingRuleNames(), ", "),
+		len(rules.Isolation.Exceptions))
+	return mcp.NewToolResultStructured(result, message), nil
+}
+
+// layerViolations reports every illegal import from packages of layer,
+// optionally within a single domain. Each broken edge is reported on its
+// own: imports of layers the layer may not depend on and imports of the
+// same layer in another domain. Imports of protected packages are reported
+// by protectedViolations.
+func layerViolations(graph *importGraph, rules *archRules, layer, domain string) []violation {
+	rule, _ := rules.layer(layer)
+
+	var violations []violation
+	graph.eachInternalImport(func(pkg *packageNode, imp importRef, target string) {
+		fromDomain, fromLayer := rules.classify(pkg.relPath)
+		if fromLayer != layer || (domain != "" && fromDomain != domain) {
+			return
+		}
+		toDomain, toLayer := rules.classify(target)
+
+		newViolation := func(message, fix string) violation {
+			return violation{
+				Rule:     ruleLayerDeps,
+				Severity: severityError,
+				Package:  pkg.importPath,
+				Import:   imp.path,
+				File:     imp.file,
+				Line:     imp.line,
+				Message:  message,
+				Fix:      fix,
+			}
+		}
+
+		if !rules.layerAllowed(fromLayer, toLayer) {
+			violations = append(violations, newViolation(
+				fmt.Sprintf("%s layer must not depend on %s layer", fromLayer, toLayer),
+				fmt.Sprintf("Invert the dependency: declare an interface in the %s layer and inject the %s implementation", fromLayer, toLayer),
+			))
+		}
+
+		if toLayer == fromLayer && !rule.CrossDomain && rules.domainsIsolated(fromDomain, toDomain) {
+			violations = append(violations, newViolation(
+				fmt.Sprintf("%s layer of %s must not depend on %s layer of %s", fromLayer, fromDomain, toLayer, toDomain),
+				fmt.Sprintf("Go through the %s domain's application layer or a shared contract instead of its %s packages", toDomain, toLayer),
+			))
+		}
+	})
+	return violations
+}
+
+// protectedViolations reports imports of protected packages by the packages
+// of layer outside them, optionally within a single domain. With an empty
+// layer it covers every package, including those that fit no layer.
+func protectedViolations(graph *importGraph, rules *archRules, layer, domain string) []violation {
+	var violations []violation
+	graph.eachInternalImport(func(pkg *packageNode, imp importRef, target string) {
+		fromDomain, fromLayer := rules.classify(pkg.relPath)
+		if (layer != "" && fromLayer != layer) || (domain != "" && fromDomain != domain) {
+			return
+		}
+		protected, ok := rules.protectedTarget(pkg.relPath, target)
+		if !ok {
+			return
+		}
+
+		importer := pkg.relPath
+		if fromLayer != "" {
+			importer = fromLayer + " layer"
+		}
+		violations = append(violations, violation{
+			Rule:     ruleLayerDeps,
+			Severity: severityError,
+			Package:  pkg.importPath,
+			Import:   imp.path,
+			File:     imp.file,
+			Line:     imp.line,
+			Message:  fmt.Sprintf("%s must not import protected package %s", importer, protected),
+			Fix:      fmt.Sprintf("Move the code %s needs out of %s into a package of an allowed layer", pkg.relPath, protected),
+		})
+	})
+	return violations
+}
+
+// domainIsolationViolations reports imports from one bounded context into
+// another that no isolation exception allows.
+func domainIsolationViolations(graph *importGraph, rules *archRules, sourceDomain, targetDomain string) []violation {
+	if !rules.domainsIsolated(sourceDomain, targetDomain) {
+		return nil
+	}
+
+	var violations []violation
+	graph.eachInternalImport(func(pkg *packageNode, imp importRef, target string) {
+		fromDomain, _ := rules.classify(pkg.relPath)
+		toDomain, _ := rules.classify(target)
+		if fromDomain != sourceDomain || toDomain != targetDomain {
+			return
+		}
+		violations = append(violations, violation{
+			Rule:     ruleDomainIsolation,
+			Severity: severityError,
+			Package:  pkg.importPath,
+			Import:   imp.path,
+			File:     imp.file,
+			Line:     imp.line,
+			Message:  fmt.Sprintf("domain %s must not depend on domain %s", sourceDomain, targetDomain),
+			Fix:      fmt.Sprintf("Talk to the %s domain through a port in %s, a domain event or a shared contract instead of importing it", targetDomain, sourceDomain),
+		})
+	})
+	return violations
+}
+
+func main() {
+	os.Exit(runCLI(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+var layeredModule = map[string]string{
+	"internal/order/domain/order.go": `package domain
+
+import (
+	_ "example.com/shop/cmd/config"
+	_ "example.com/shop/internal/order/application"
+	_ "example.com/shop/internal/order/infrastructure"
+	_ "example.com/shop/internal/shared/money"
+)
+`,
+	"internal/order/application/service.go": `package application
+
+import (
+	_ "example.com/shop/internal/billing/application"
+	_ "example.com/shop/internal/order/domain"
+	_ "example.com/shop/internal/shared/money"
+)
+`,
+	"internal/order/infrastructure/repo.go": `package infrastructure
+
+import (
+	_ "example.com/shop/internal/order/application"
+	_ "example.com/shop/internal/order/domain"
+)
+`,
+	"internal/order/events/events.go": `package events
+
+import _ "example.com/shop/cmd/config"
+`,
+	"internal/billing/application/billing.go": "package application\n",
+	"internal/shared/money/money.go":          "package money\n",
+	"cmd/config/config.go":                    "package config\n",
+	"cmd/app/main.go": `package main
+
+import (
+	_ "example.com/shop/cmd/config"
+	_ "example.com/shop/internal/order/infrastructure"
+)
+
+func main() {}
+`,
+}
+
+// describeViolations renders violations as "<package path>:<line>: <message>"
+// in report order.
+func describeViolations(graph *importGraph, violations []violation) []string {
+	var got []string
+	for _, v := range newCheckReport("test", violations).Violations {
+		rel, _ := graph.relImport(v.Package)
+		got = append(got, rel+": "+v.Message)
+	}
+	return got
+}
+
+func TestLayerViolations(t *testing.T) {
+	tests := []struct {
+		layer, domain string
+		want          []string
+	}{
+		{
+			layer: "domain",
+			want: []string{
+				"internal/order/domain: domain layer must not depend on application layer",
+				"internal/order/domain: domain layer must not depend on infrastructure layer",
+			},
+		},
+		{
+			layer: "application",
+			want:  []string{"internal/order/application: application layer of order must not depend on application layer of billing"},
+		},
+		{layer: "application", domain: "billing"},
+		{layer: "infrastructure"},
+	}
+
+	graph := loadModule(t, layeredModule)
+	for _, tt := range tests {
+		t.Run(tt.layer+"/"+tt.domain, func(t *testing.T) {
+			violations := layerViolations(graph, defaultRules(), tt.layer, tt.domain)
+			if got := describeViolations(graph, violations); !slices.Equal(got, tt.want) {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+			for _, v := range violations {
+				if v.Rule != ruleLayerDeps || v.File == "" || v.Line == 0 || v.Import == "" {
+					t.Errorf("violation %+v lacks its rule or location", v)
+				}
+			}
+		})
+	}
+}
+
+func TestLayerViolationsCrossDomainLayer(t *testing.T) {
+	rules := defaultRules()
+	rules.Layers[1].CrossDomain = true
+	graph := loadModule(t, layeredModule)
+	if got := describeViolations(graph, layerViolations(graph, rules, "application", "")); len(got) > 0 {
+		t.Errorf("crossDomain layer reported %q", got)
+	}
+}
+
+func TestProtectedViolations(t *testing.T) {
+	const (
+		fromDomain = "internal/order/domain: domain layer must not import protected package cmd"
+		fromEvents = "internal/order/events: internal/order/events must not import protected package cmd"
+	)
+	tests := []struct {
+		layer, domain string
+		want          []string
+	}{
+		{want: []string{fromDomain, fromEvents}},
+		{domain: "order", want: []string{fromDomain, fromEvents}},
+		{domain: "billing"},
+		{layer: "domain", want: []string{fromDomain}},
+		{layer: "application"},
+	}
+
+	graph := loadModule(t, layeredModule)
+	for _, tt := range tests {
+		t.Run(tt.layer+"/"+tt.domain, func(t *testing.T) {
+			got := describeViolations(graph, protectedViolations(graph, defaultRules(), tt.layer, tt.domain))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDomainIsolationViolations(t *testing.T) {
+	excepted := defaultRules()
+	excepted.Isolation.Exceptions = []isolationException{{From: "order", To: "bill*"}}
+
+	tests := []struct {
+		name     string
+		rules    *archRules
+		from, to string
+		want     []string
+	}{
+		{
+			name: "order imports billing",
+			from: "order", to: "billing",
+			want: []string{"internal/order/application: domain order must not depend on domain billing"},
+		},
+		{name: "billing imports nothing", from: "billing", to: "order"},
+		{name: "the shared kernel is no domain", from: "order", to: "shared"},
+		{name: "exception", rules: excepted, from: "order", to: "billing"},
+	}
+
+	graph := loadModule(t, layeredModule)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := tt.rules
+			if rules == nil {
+				rules = defaultRules()
+			}
+			got := describeViolations(graph, domainIsolationViolations(graph, rules, tt.from, tt.to))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// testEvent is one line of `go test -json` output (see `go doc test2json`).
+// Build errors arrive as build-output events keyed by ImportPath, and the
+// package's fail event names that build in FailedBuild.
+type testEvent struct {
+	Time        time.Time `json:"Time"`
+	Action      string    `json:"Action"`
+	Package     string    `json:"Package"`
+	ImportPath  string    `json:"ImportPath"`
+	Test        string    `json:"Test"`
+	Elapsed     float64   `json:"Elapsed"`
+	Output      string    `json:"Output"`
+	FailedBuild string    `json:"FailedBuild"`
+}
+
+// testCaseResult is the outcome of a single test or subtest.
+type testCaseResult struct {
+	Name    string  `json:"name"`
+	Package string  `json:"package"`
+	Status  string  `json:"status"`
+	Elapsed float64 `json:"elapsed"`
+	Output  string  `json:"output,omitempty"`
+}
+
+// packageResult is the outcome of a test package. Output is kept for
+// failures that happen outside any test, such as build errors.
+type packageResult struct {
+	Package string  `json:"package"`
+	Status  string  `json:"status"`
+	Elapsed float64 `json:"elapsed"`
+	Output  string  `json:"output,omitempty"`
+}
+
+// parseTestEvents folds a `go test -json` stream into per-test and
+// per-package results. Tests that started but never finished, because the
+// run was stopped, keep the status "running". Output is only retained for
+// failed and unfinished tests. Lines that are not JSON events, such as go
+// command errors, are returned separately.
+func parseTestEvents(stream []byte) ([]testCaseResult, []packageResult, string) {
+	type key struct{ pkg, test string }
+	outputs := make(map[key]*strings.Builder)
+	tests := make(map[key]*testCaseResult)
+	packages := make(map[string]*packageResult)
+	var testOrder []key
+	var packageOrder []string
+	var other strings.Builder
+
+	scanner := bufio.NewScanner(bytes.NewReader(stream))
+	scanner.Buffer(make([]byte, 0, 64*1024), 8*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var ev testEvent
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &ev) != nil || ev.Action == "" {
+			other.Write(line)
+			other.WriteByte('\n')
+			continue
+		}
+
+		if ev.Action == "build-output" {
+			ev.Package = ev.ImportPath
+		}
+		k := key{ev.Package, ev.Test}
+		switch ev.Action {
+		case "output", "build-output":
+			if outputs[k] == nil {
+				outputs[k] = &strings.Builder{}
+			}
+			outputs[k].WriteString(ev.Output)
+		case "run":
+			if ev.Test != "" {
+				if _, ok := tests[k]; !ok {
+					testOrder = append(testOrder, k)
+				}
+				tests[k] = &testCaseResult{Name: ev.Test, Package: ev.Package, Status: "running"}
+			}
+		case "pass", "fail", "skip":
+			if ev.Test == "" {
+				if _, ok := packages[ev.Package]; !ok {
+					packageOrder = append(packageOrder, ev.Package)
+				}
+				packages[ev.Package] = &packageResult{Package: ev.Package, Status: ev.Action, Elapsed: ev.Elapsed}
+				if build := outputs[key{ev.FailedBuild, ""}]; ev.FailedBuild != "" && build != nil {
+					if outputs[k] == nil {
+						outputs[k] = &strings.Builder{}
+					}
+					outputs[k].WriteString(build.String())
+				}
+				continue
+			}
+			if _, ok := tests[k]; !ok {
+				testOrder = append(testOrder, k)
+			}
+			tests[k] = &testCaseResult{Name: ev.Test, Package: ev.Package, Status: ev.Action, Elapsed: ev.Elapsed}
+		}
+	}
+
+	var testResults []testCaseResult
+	for _, k := range testOrder {
+		t := tests[k]
+		if (t.Status == "fail" || t.Status == "running") && outputs[k] != nil {
+			t.Output = outputs[k].String()
+		}
+		testResults = append(testResults, *t)
+	}
+
+	var packageResults []packageResult
+	for _, name := range packageOrder {
+		p := packages[name]
+		if p.Status == "fail" && outputs[key{name, ""}] != nil {
+			p.Output = outputs[key{name, ""}].String()
+		}
+		packageResults = append(packageResults, *p)
+	}
+	sort.SliceStable(packageResults, func(i, j int) bool { return packageResults[i].Package < packageResults[j].Package })
+
+	return testResults, packageResults, other.String()
+}
+
+// summary counts the tests of a run by status.
+func (r testRun) summary() string {
+	counts := make(map[string]int)
+	for _, t := range r.Tests {
+		counts[t.Status]++
+	}
+	summary := fmt.Sprintf("%d passed, %d failed, %d skipped", counts["pass"], counts["fail"], counts["skip"])
+	if counts["running"] > 0 {
+		summary += fmt.Sprintf(", %d unfinished", counts["running"])
+	}
+	return summary
+}
+
+// failures renders the output of every failed or unfinished test, and of
+// failed packages without a failing test, which usually did not build.
+func (r testRun) failures() string {
+	var b strings.Builder
+	failedTests := make(map[string]bool)
+	for _, t := range r.Tests {
+		switch t.Status {
+		case "fail":
+			failedTests[t.Package] = true
+			fmt.Fprintf(&b, "--- FAIL: %s (%s, %.2fs)\n%s\n", t.Name, t.Package, t.Elapsed, strings.TrimRight(t.Output, "\n"))
+		case "running":
+			fmt.Fprintf(&b, "--- UNFINISHED: %s (%s)\n%s\n", t.Name, t.Package, strings.TrimRight(t.Output, "\n"))
+		}
+	}
+	for _, p := range r.Packages {
+		if p.Status == "fail" && p.Output != "" && !failedTests[p.Package] {
+			fmt.Fprintf(&b, "FAIL %s\n%s\n", p.Package, strings.TrimRight(p.Output, "\n"))
+		}
+	}
+	if r.Output != "" {
+		b.WriteString(r.Output)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
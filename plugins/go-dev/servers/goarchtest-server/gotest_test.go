@@ -0,0 +1,143 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTestEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		stream   []string
+		tests    []testCaseResult
+		packages []packageResult
+		other    string
+	}{
+		{
+			name: "passing and failing tests",
+			stream: []string{
+				`{"Action":"start","Package":"ex/a"}`,
+				`{"Action":"run","Package":"ex/a","Test":"TestOK"}`,
+				`{"Action":"output","Package":"ex/a","Test":"TestOK","Output":"=== RUN   TestOK\n"}`,
+				`{"Action":"pass","Package":"ex/a","Test":"TestOK","Elapsed":0.01}`,
+				`{"Action":"run","Package":"ex/a","Test":"TestBad"}`,
+				`{"Action":"output","Package":"ex/a","Test":"TestBad","Output":"=== RUN   TestBad\n"}`,
+				`{"Action":"output","Package":"ex/a","Test":"TestBad","Output":"    a_test.go:9: got 1, want 2\n"}`,
+				`{"Action":"fail","Package":"ex/a","Test":"TestBad","Elapsed":0.02}`,
+				`{"Action":"output","Package":"ex/a","Output":"FAIL\n"}`,
+				`{"Action":"fail","Package":"ex/a","Elapsed":0.5}`,
+			},
+			tests: []testCaseResult{
+				{Name: "TestOK", Package: "ex/a", Status: "pass", Elapsed: 0.01},
+				{Name: "TestBad", Package: "ex/a", Status: "fail", Elapsed: 0.02, Output: "=== RUN   TestBad\n    a_test.go:9: got 1, want 2\n"},
+			},
+			packages: []packageResult{
+				{Package: "ex/a", Status: "fail", Elapsed: 0.5, Output: "FAIL\n"},
+			},
+		},
+		{
+			name: "build failure",
+			stream: []string{
+				`{"ImportPath":"ex/a [ex/a.test]","Action":"build-output","Output":"# ex/a [ex/a.test]\n"}`,
+				`{"ImportPath":"ex/a [ex/a.test]","Action":"build-output","Output":"a/a.go:3:1: syntax error\n"}`,
+				`{"ImportPath":"ex/a [ex/a.test]","Action":"build-fail"}`,
+				`{"Action":"start","Package":"ex/a"}`,
+				`{"Action":"output","Package":"ex/a","Output":"FAIL\tex/a [build failed]\n"}`,
+				`{"Action":"fail","Package":"ex/a","Elapsed":0,"FailedBuild":"ex/a [ex/a.test]"}`,
+			},
+			packages: []packageResult{
+				{Package: "ex/a", Status: "fail", Output: "FAIL\tex/a [build failed]\n# ex/a [ex/a.test]\na/a.go:3:1: syntax error\n"},
+			},
+		},
+		{
+			name: "package fails outside any test",
+			stream: []string{
+				`{"Action":"start","Package":"ex/a"}`,
+				`{"Action":"run","Package":"ex/a","Test":"TestOK"}`,
+				`{"Action":"pass","Package":"ex/a","Test":"TestOK","Elapsed":0.01}`,
+				`{"Action":"output","Package":"ex/a","Output":"panic in TestMain\n"}`,
+				`{"Action":"output","Package":"ex/a","Output":"FAIL\tex/a\t0.1s\n"}`,
+				`{"Action":"fail","Package":"ex/a","Elapsed":0.1}`,
+			},
+			tests: []testCaseResult{
+				{Name: "TestOK", Package: "ex/a", Status: "pass", Elapsed: 0.01},
+			},
+			packages: []packageResult{
+				{Package: "ex/a", Status: "fail", Elapsed: 0.1, Output: "panic in TestMain\nFAIL\tex/a\t0.1s\n"},
+			},
+		},
+		{
+			name: "skipped tests and packages keep no output",
+			stream: []string{
+				`{"Action":"run","Package":"ex/a","Test":"TestSlow"}`,
+				`{"Action":"output","Package":"ex/a","Test":"TestSlow","Output":"    a_test.go:5: short mode\n"}`,
+				`{"Action":"skip","Package":"ex/a","Test":"TestSlow"}`,
+				`{"Action":"output","Package":"ex/a","Output":"ok  \tex/a\t0.1s\n"}`,
+				`{"Action":"pass","Package":"ex/a","Elapsed":0.1}`,
+				`{"Action":"output","Package":"ex/b","Output":"?   \tex/b\t[no test files]\n"}`,
+				`{"Action":"skip","Package":"ex/b"}`,
+			},
+			tests: []testCaseResult{
+				{Name: "TestSlow", Package: "ex/a", Status: "skip"},
+			},
+			packages: []packageResult{
+				{Package: "ex/a", Status: "pass", Elapsed: 0.1},
+				{Package: "ex/b", Status: "skip"},
+			},
+		},
+		{
+			name: "interleaved packages and subtests",
+			stream: []string{
+				`{"Action":"run","Package":"ex/b","Test":"TestB"}`,
+				`{"Action":"run","Package":"ex/a","Test":"TestA"}`,
+				`{"Action":"run","Package":"ex/a","Test":"TestA/sub"}`,
+				`{"Action":"output","Package":"ex/b","Test":"TestB","Output":"b1\n"}`,
+				`{"Action":"output","Package":"ex/a","Test":"TestA/sub","Output":"a1\n"}`,
+				`{"Action":"output","Package":"ex/b","Test":"TestB","Output":"b2\n"}`,
+				`{"Action":"fail","Package":"ex/a","Test":"TestA/sub","Elapsed":0.01}`,
+				`{"Action":"fail","Package":"ex/a","Test":"TestA","Elapsed":0.02}`,
+				`{"Action":"fail","Package":"ex/b","Test":"TestB","Elapsed":0.03}`,
+				`{"Action":"fail","Package":"ex/b","Elapsed":0.3}`,
+				`{"Action":"fail","Package":"ex/a","Elapsed":0.2}`,
+			},
+			tests: []testCaseResult{
+				{Name: "TestB", Package: "ex/b", Status: "fail", Elapsed: 0.03, Output: "b1\nb2\n"},
+				{Name: "TestA", Package: "ex/a", Status: "fail", Elapsed: 0.02},
+				{Name: "TestA/sub", Package: "ex/a", Status: "fail", Elapsed: 0.01, Output: "a1\n"},
+			},
+			packages: []packageResult{
+				{Package: "ex/a", Status: "fail", Elapsed: 0.2},
+				{Package: "ex/b", Status: "fail", Elapsed: 0.3},
+			},
+		},
+		{
+			name: "stopped run and go command errors",
+			stream: []string{
+				`go: downloading example.com/dep v1.0.0`,
+				`{"Action":"run","Package":"ex/a","Test":"TestHang"}`,
+				`{"Action":"output","Package":"ex/a","Test":"TestHang","Output":"waiting\n"}`,
+				`not json {`,
+			},
+			tests: []testCaseResult{
+				{Name: "TestHang", Package: "ex/a", Status: "running", Output: "waiting\n"},
+			},
+			other: "go: downloading example.com/dep v1.0.0\nnot json {\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTests, gotPackages, gotOther := parseTestEvents([]byte(strings.Join(tt.stream, "\n")))
+			if !reflect.DeepEqual(gotTests, tt.tests) {
+				t.Errorf("tests = %+v, want %+v", gotTests, tt.tests)
+			}
+			if !reflect.DeepEqual(gotPackages, tt.packages) {
+				t.Errorf("packages = %+v, want %+v", gotPackages, tt.packages)
+			}
+			if gotOther != tt.other {
+				t.Errorf("other = %q, want %q", gotOther, tt.other)
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// importGraph is the in-process view of a module: every package found under
+// the module root together with the imports each of its files declares.
+type importGraph struct {
+	root       string
+	modulePath string
+	fset       *token.FileSet
+	packages   map[string]*packageNode
+
+	// typesMu guards the type-checked packages, which are computed on first
+	// use. previous is the graph this one replaced in the cache; checking
+	// reuses its packages that did not change and then releases it.
+	typesMu  sync.Mutex
+	typed    map[string]*types.Package
+	previous *importGraph
+
+	// sweepMu guards the result of the last full sweep, which stays valid as
+	// long as the graph is cached and the rules are not reloaded.
+	sweepMu    sync.Mutex
+	sweptRules *archRules
+	swept      []violation
+	sweptIn    []string
+}
+
+// packageNode is a single package directory of the analyzed module.
+type packageNode struct {
+	importPath string
+	relPath    string
+	dir        string
+	name       string
+	files      []*sourceFile
+}
+
+// sourceFile is a parsed non-test Go file belonging to a packageNode. A file
+// with syntax errors keeps the partial AST the parser recovered, and parseErr
+// describes the first error.
+type sourceFile struct {
+	relPath      string
+	ast          *ast.File
+	imports      []importRef
+	suppressions []suppression
+	parseErr     string
+}
+
+// importRef is one import spec, with the position it was declared at.
+type importRef struct {
+	path string
+	file string
+	line int
+}
+
+// loadImportGraph parses every non-test Go file below root and groups them
+// into packages. It only needs go.mod to resolve the module path, so the
+// project does not have to build or depend on anything for the graph to load.
+func loadImportGraph(root string) (*importGraph, error) {
+	modulePath, err := readModulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := scanModule(root)
+	if err != nil {
+		return nil, err
+	}
+	return parseModule(root, modulePath, dirs)
+}
+
+// parseModule parses the files scanModule listed in dirs.
+func parseModule(root, modulePath string, dirs map[string][]fileStamp) (*importGraph, error) {
+	g := newImportGraph(root, modulePath, token.NewFileSet())
+	for relDir, files := range dirs {
+		pkg, err := g.parsePackage(relDir, files)
+		if err != nil {
+			return nil, err
+		}
+		if len(pkg.files) > 0 {
+			g.packages[pkg.importPath] = pkg
+		}
+	}
+	return g, nil
+}
+
+func newImportGraph(root, modulePath string, fset *token.FileSet) *importGraph {
+	return &importGraph{
+		root:       root,
+		modulePath: modulePath,
+		fset:       fset,
+		packages:   make(map[string]*packageNode),
+	}
+}
+
+// fileStamp identifies one version of a source file on disk.
+type fileStamp struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// scanModule lists the non-test Go files of every package directory below
+// root, keyed by module-relative directory, without reading them.
+func scanModule(root string) (map[string][]fileStamp, error) {
+	dirs := make(map[string][]fileStamp)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			// Nested modules are analyzed on their own.
+			if p != root {
+				if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") || strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		dirs[rel] = append(dirs[rel], fileStamp{name: d.Name(), size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading packages from %s: %w", root, err)
+	}
+	return dirs, nil
+}
+
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+		name == "vendor" || name == "testdata"
+}
+
+// parsePackage parses the given files of the package at relDir, skipping
+// the files that build constraints exclude on this platform, as the go
+// command does, so that e.g. //go:build ignore generators are not analyzed.
+// Syntax errors do not stop the analysis: while a file is being edited, its
+// imports and declarations are read as far as they parse.
+func (g *importGraph) parsePackage(relDir string, files []fileStamp) (*packageNode, error) {
+	pkg := &packageNode{
+		importPath: g.importPathOf(relDir),
+		relPath:    relDir,
+		dir:        filepath.Join(g.root, filepath.FromSlash(relDir)),
+	}
+
+	for _, stamp := range files {
+		p := filepath.Join(pkg.dir, stamp.name)
+		if match, err := build.Default.MatchFile(pkg.dir, stamp.name); err == nil && !match {
+			continue
+		}
+		rel := path.Join(relDir, stamp.name)
+		f, err := parser.ParseFile(g.fset, p, nil, parser.ParseComments|parser.SkipObjectResolution)
+		var syntaxErrs scanner.ErrorList
+		if err != nil && !errors.As(err, &syntaxErrs) {
+			return nil, fmt.Errorf("parsing %s: %w", p, err)
+		}
+		parseErr := describeSyntaxErrors(rel, syntaxErrs)
+		if f.Name.Name == "" {
+			// Without a package clause there is nothing to analyze.
+			pkg.files = append(pkg.files, &sourceFile{relPath: rel, ast: f, parseErr: parseErr})
+			continue
+		}
+		if pkg.name == "" {
+			pkg.name = f.Name.Name
+		}
+
+		sf := &sourceFile{relPath: rel, ast: f, parseErr: parseErr, suppressions: parseSuppressions(g.fset, f, rel, pkg.importPath)}
+		for _, spec := range f.Imports {
+			ip, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			sf.imports = append(sf.imports, importRef{
+				path: ip,
+				file: rel,
+				line: g.fset.Position(spec.Pos()).Line,
+			})
+		}
+		pkg.files = append(pkg.files, sf)
+	}
+
+	return pkg, nil
+}
+
+// describeSyntaxErrors reports the first of errs at the module-relative path
+// rel, or "" when there are none.
+func describeSyntaxErrors(rel string, errs scanner.ErrorList) string {
+	if len(errs) == 0 {
+		return ""
+	}
+	first := errs[0]
+	msg := fmt.Sprintf("%s:%d:%d: %s", rel, first.Pos.Line, first.Pos.Column, first.Msg)
+	if len(errs) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(errs)-1)
+	}
+	return msg
+}
+
+// parseErrors lists the syntax errors of the graph's files, one per file.
+func (g *importGraph) parseErrors() []string {
+	var errs []string
+	for _, pkg := range g.sortedPackages() {
+		for _, f := range pkg.files {
+			if f.parseErr != "" {
+				errs = append(errs, f.parseErr)
+			}
+		}
+	}
+	return errs
+}
+
+// readModulePath returns the module directive of the go.mod at p.
+func readModulePath(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", fmt.Errorf("reading go.mod: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			rest = strings.TrimSpace(rest)
+			if i := strings.Index(rest, "//"); i >= 0 {
+				rest = strings.TrimSpace(rest[:i])
+			}
+			if unquoted, err := strconv.Unquote(rest); err == nil {
+				rest = unquoted
+			}
+			if rest != "" {
+				return rest, nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading go.mod: %w", err)
+	}
+	return "", fmt.Errorf("no module directive in %s", p)
+}
+
+// subgraph returns a graph of the packages at the given module-relative
+// paths. Their imports of packages outside it still resolve to the module.
+func (g *importGraph) subgraph(rels []string) *importGraph {
+	sub := newImportGraph(g.root, g.modulePath, g.fset)
+	for _, rel := range rels {
+		if pkg, ok := g.packages[g.importPathOf(rel)]; ok {
+			sub.packages[pkg.importPath] = pkg
+		}
+	}
+	return sub
+}
+
+// sortedPackages returns the packages ordered by import path so that every
+// report lists them deterministically.
+func (g *importGraph) sortedPackages() []*packageNode {
+	pkgs := make([]*packageNode, 0, len(g.packages))
+	for _, pkg := range g.packages {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].importPath < pkgs[j].importPath })
+	return pkgs
+}
+
+// relImport maps an import path back to a module-relative directory. The
+// second result is false for imports outside the module.
+func (g *importGraph) relImport(importPath string) (string, bool) {
+	if importPath == g.modulePath {
+		return ".", true
+	}
+	rel, ok := strings.CutPrefix(importPath, g.modulePath+"/")
+	return rel, ok
+}
+
+// importPathOf is the inverse of relImport.
+func (g *importGraph) importPathOf(rel string) string {
+	if rel == "." {
+		return g.modulePath
+	}
+	return g.modulePath + "/" + rel
+}
+
+// packageFile returns the module-relative path of the first file, by name,
+// of the package with the given import path. It locates findings about the
+// package as a whole.
+func (g *importGraph) packageFile(importPath string) (string, bool) {
+	pkg := g.packages[importPath]
+	if pkg == nil || len(pkg.files) == 0 {
+		return "", false
+	}
+	return pkg.files[0].relPath, true
+}
+
+// eachInternalImport calls fn for every import that resolves to another
+// package of the module, passing the module-relative path of the target.
+func (g *importGraph) eachInternalImport(fn func(pkg *packageNode, imp importRef, target string)) {
+	for _, pkg := range g.sortedPackages() {
+		for _, f := range pkg.files {
+			for _, imp := range f.imports {
+				if rel, ok := g.relImport(imp.path); ok && rel != pkg.relPath {
+					fn(pkg, imp, rel)
+				}
+			}
+		}
+	}
+}
+
+// declaredTypes returns the names of every type declared in pkg.
+func (pkg *packageNode) declaredTypes() []string {
+	var names []string
+	pkg.eachTypeSpec(func(_ *sourceFile, spec *ast.TypeSpec) {
+		names = append(names, spec.Name.Name)
+	})
+	return names
+}
+
+// eachTypeSpec calls fn for every type declared at the top level of pkg.
+func (pkg *packageNode) eachTypeSpec(fn func(f *sourceFile, spec *ast.TypeSpec)) {
+	for _, f := range pkg.files {
+		for _, decl := range f.ast.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				fn(f, spec.(*ast.TypeSpec))
+			}
+		}
+	}
+}
+
+// methods returns the names of the methods pkg declares on typeName, with
+// either a value or a pointer receiver.
+func (pkg *packageNode) methods(typeName string) []string {
+	var names []string
+	for _, f := range pkg.files {
+		for _, decl := range f.ast.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+				continue
+			}
+			if receiverType(fn.Recv.List[0].Type) == typeName {
+				names = append(names, fn.Name.Name)
+			}
+		}
+	}
+	return names
+}
+
+// receiverType returns the base type name of a method receiver, stripping
+// pointers and type parameters.
+func receiverType(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates the files, keyed by slash-separated path, below root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeModule creates a module named example.com/shop in a temporary
+// directory from files and returns its root.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"go.mod": "module example.com/shop\n\ngo 1.22\n"})
+	writeFiles(t, root, files)
+	return root
+}
+
+// loadModule writes files as a module and loads its import graph.
+func loadModule(t *testing.T, files map[string]string) *importGraph {
+	t.Helper()
+	graph, err := loadImportGraph(writeModule(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graph
+}
+
+func TestLoadImportGraph(t *testing.T) {
+	graph := loadModule(t, map[string]string{
+		"main.go":                             "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/shop/internal/order/domain\"\n)\n",
+		"internal/order/domain/order.go":      "package domain\n\nimport \"errors\"\n",
+		"internal/order/domain/order_test.go": "package domain\n\nimport \"testing\"\n",
+		"internal/order/domain/notes.txt":     "not Go\n",
+		"internal/order/testdata/fixture.go":  "package testdata\n",
+		"vendor/example.com/lib/lib.go":       "package lib\n",
+		".cache/gen.go":                       "package cache\n",
+		"_old/old.go":                         "package old\n",
+		"tools/go.mod":                        "module example.com/tools\n",
+		"tools/tools.go":                      "package tools\n",
+	})
+
+	var got []string
+	for _, pkg := range graph.sortedPackages() {
+		for _, f := range pkg.files {
+			got = append(got, pkg.importPath+" "+pkg.name+" "+f.relPath)
+		}
+	}
+	want := []string{
+		"example.com/shop main main.go",
+		"example.com/shop/internal/order/domain domain internal/order/domain/order.go",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+
+	var imports []string
+	for _, imp := range graph.packages["example.com/shop"].files[0].imports {
+		imports = append(imports, fmt.Sprintf("%s:%d %s", imp.file, imp.line, imp.path))
+	}
+	wantImports := []string{"main.go:4 fmt", "main.go:6 example.com/shop/internal/order/domain"}
+	if !slices.Equal(imports, wantImports) {
+		t.Errorf("imports = %q, want %q", imports, wantImports)
+	}
+}
+
+func TestLoadImportGraphSyntaxErrors(t *testing.T) {
+	graph := loadModule(t, map[string]string{
+		"internal/order/domain/order.go": "package domain\n\nimport \"os\"\n\nfunc broken( {\n",
+		"internal/order/domain/item.go":  "package domain\n\ntype Item struct{}\n",
+		"internal/order/app/empty.go":    "// no package clause\n",
+	})
+
+	pkg := graph.packages["example.com/shop/internal/order/domain"]
+	if pkg == nil || len(pkg.files) != 2 {
+		t.Fatalf("the domain package lost files with syntax errors: %+v", pkg)
+	}
+	var imports []string
+	for _, f := range pkg.files {
+		for _, imp := range f.imports {
+			imports = append(imports, imp.path)
+		}
+	}
+	if !slices.Equal(imports, []string{"os"}) {
+		t.Errorf("imports = %q, want the os import of the broken file", imports)
+	}
+	if types := pkg.declaredTypes(); !slices.Equal(types, []string{"Item"}) {
+		t.Errorf("declared types = %q, want Item", types)
+	}
+
+	errs := graph.parseErrors()
+	if len(errs) != 2 || !strings.HasPrefix(errs[0], "internal/order/app/empty.go:") || !strings.HasPrefix(errs[1], "internal/order/domain/order.go:5:") {
+		t.Errorf("parse errors = %q", errs)
+	}
+}
+
+func TestLoadImportGraphBuildConstraints(t *testing.T) {
+	graph := loadModule(t, map[string]string{
+		"internal/order/domain/order.go":   "package domain\n",
+		"internal/order/domain/gen.go":     "//go:build ignore\n\npackage main\n\nimport \"os\"\n",
+		"internal/order/domain/z_plan9.go": "package domain\n\nimport \"syscall\"\n",
+		"tools/gen.go":                     "//go:build ignore\n\npackage main\n",
+	})
+
+	var got []string
+	for _, pkg := range graph.sortedPackages() {
+		for _, f := range pkg.files {
+			got = append(got, f.relPath)
+		}
+	}
+	if !slices.Equal(got, []string{"internal/order/domain/order.go"}) {
+		t.Errorf("files = %q, want only the files that build on this platform", got)
+	}
+}
+
+func TestReadModulePath(t *testing.T) {
+	tests := []struct {
+		gomod string
+		want  string
+	}{
+		{gomod: "module example.com/shop\n", want: "example.com/shop"},
+		{gomod: "// comment\nmodule \"example.com/quoted\" // trailing\n\ngo 1.22\n", want: "example.com/quoted"},
+		{gomod: "modulex example.com/shop\n"},
+		{gomod: "go 1.22\n"},
+	}
+	for _, tt := range tests {
+		p := filepath.Join(t.TempDir(), "go.mod")
+		if err := os.WriteFile(p, []byte(tt.gomod), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readModulePath(p)
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("readModulePath(%q) = %q, %v, want %q", tt.gomod, got, err, tt.want)
+		}
+	}
+}
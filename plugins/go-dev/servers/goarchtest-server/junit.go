@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// noDomain names the JUnit test case of violations outside every domain.
+const noDomain = "(no domain)"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// junitReport renders report as JUnit XML with one test suite per rule the
+// check evaluated and one test case per checked domain, so that dashboards
+// show which rule broke in which bounded context. A test case fails when the
+// domain's packages raised error violations of the rule. domainOf returns
+// the domain of a package's import path.
+func junitReport(report checkReport, domainOf func(importPath string) string, domains []string) ([]byte, error) {
+	byCase := make(map[[2]string][]violation)
+	ruleIDs := checkedRules(report.Check)
+	for _, v := range report.Violations {
+		domain := domainOf(v.Package)
+		if domain == "" {
+			domain = noDomain
+		}
+		byCase[[2]string{v.Rule, domain}] = append(byCase[[2]string{v.Rule, domain}], v)
+		if !slices.Contains(ruleIDs, v.Rule) {
+			ruleIDs = append(ruleIDs, v.Rule)
+		}
+	}
+
+	doc := junitTestSuites{Name: serverName}
+	for _, rule := range ruleIDs {
+		cases := slices.Clone(domains)
+		for domain := range byCase {
+			if domain[0] == rule && !slices.Contains(cases, domain[1]) {
+				cases = append(cases, domain[1])
+			}
+		}
+		slices.Sort(cases)
+
+		suite := junitTestSuite{Name: rule}
+		for _, domain := range cases {
+			tc := junitTestCase{Name: domain, ClassName: rule}
+			var failing []violation
+			for _, v := range byCase[[2]string{rule, domain}] {
+				if v.Severity == severityError {
+					failing = append(failing, v)
+				}
+			}
+			if len(failing) > 0 {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("%d %s violation(s) in %s", len(failing), rule, domain),
+					Type:    rule,
+					Text:    junitFailureText(failing),
+				}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+			suite.Tests++
+		}
+
+		doc.Suites = append(doc.Suites, suite)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func junitFailureText(violations []violation) string {
+	var b strings.Builder
+	for _, v := range violations {
+		b.WriteString(v.String())
+		if v.Fix != "" {
+			b.WriteString("\n  fix: " + v.Fix)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestJunitReport(t *testing.T) {
+	report := newCheckReport("check_port_implementations", []violation{
+		{Rule: rulePorts, Severity: severityError, Package: "ex/order/application", Message: "uses a concrete adapter", Fix: "Depend on the port"},
+		{Rule: rulePorts, Severity: severityError, Package: "ex/order/application", Message: "second"},
+		{Rule: rulePorts, Severity: severityWarning, Package: "ex/billing/adapters", Message: "implements no port"},
+		{Rule: rulePorts, Severity: severityError, Package: "ex/cmd", Message: "outside every domain"},
+		{Rule: ruleNaming, Severity: severityError, Package: "ex/order/domain", Message: "not checked by this check"},
+	})
+	domainOf := func(importPath string) string {
+		switch {
+		case strings.HasPrefix(importPath, "ex/order/"):
+			return "order"
+		case strings.HasPrefix(importPath, "ex/billing/"):
+			return "billing"
+		}
+		return ""
+	}
+
+	data, err := junitReport(report, domainOf, []string{"order", "billing", "shipping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Errorf("report does not start with the XML header:\n%s", data)
+	}
+	var doc junitTestSuites
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, data)
+	}
+
+	type result struct {
+		suite, testCase string
+		failed          bool
+	}
+	want := []result{
+		{rulePorts, noDomain, true},
+		{rulePorts, "billing", false},
+		{rulePorts, "order", true},
+		{rulePorts, "shipping", false},
+		{ruleNaming, "billing", false},
+		{ruleNaming, "order", true},
+		{ruleNaming, "shipping", false},
+	}
+	var got []result
+	for _, s := range doc.Suites {
+		for _, c := range s.Cases {
+			if c.ClassName != s.Name {
+				t.Errorf("case %s of suite %s has class %s", c.Name, s.Name, c.ClassName)
+			}
+			got = append(got, result{s.Name, c.Name, c.Failure != nil})
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("cases = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("case %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if doc.Tests != 7 || doc.Failures != 3 {
+		t.Errorf("totals = %d tests, %d failures, want 7, 3", doc.Tests, doc.Failures)
+	}
+	if s := doc.Suites[0]; s.Tests != 4 || s.Failures != 2 {
+		t.Errorf("suite %s = %d tests, %d failures, want 4, 2", s.Name, s.Tests, s.Failures)
+	}
+
+	order := doc.Suites[0].Cases[2].Failure
+	if order.Message != "2 ports violation(s) in order" || order.Type != rulePorts {
+		t.Errorf("failure = %q of type %q", order.Message, order.Type)
+	}
+	if !strings.Contains(order.Text, "uses a concrete adapter\n  fix: Depend on the port\n") || !strings.Contains(order.Text, "second") {
+		t.Errorf("failure text = %q", order.Text)
+	}
+}
+
+func TestJunitReportWithoutViolations(t *testing.T) {
+	data, err := junitReport(newCheckReport("detect_cycles", nil), func(string) string { return "" }, []string{"order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc junitTestSuites
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Suites) != 1 || doc.Suites[0].Name != ruleCycle || doc.Tests != 1 || doc.Failures != 0 {
+		t.Errorf("report = %+v, want one passing %s case", doc, ruleCycle)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Zones of the abstractness/instability plane far from the main sequence.
+const (
+	zonePain        = "pain"
+	zoneUselessness = "uselessness"
+	// zoneDistance is the distance from the main sequence beyond which a
+	// component is reported in one of the zones.
+	zoneDistance = 0.5
+)
+
+// componentMetrics are Robert C. Martin's package metrics for a package or a
+// group of packages. Couplings count packages of the module: dependencies on
+// the standard library and third-party modules are not counted.
+type componentMetrics struct {
+	Name         string  `json:"name"`
+	Domain       string  `json:"domain,omitempty"`
+	Layer        string  `json:"layer,omitempty"`
+	Packages     int     `json:"packages"`
+	Afferent     int     `json:"ca"`
+	Efferent     int     `json:"ce"`
+	Types        int     `json:"types"`
+	Interfaces   int     `json:"interfaces"`
+	Instability  float64 `json:"instability"`
+	Abstractness float64 `json:"abstractness"`
+	Distance     float64 `json:"distance"`
+	Zone         string  `json:"zone,omitempty"`
+}
+
+// metricsReport is the structured result of package_metrics.
+type metricsReport struct {
+	Check    string             `json:"check"`
+	Packages []componentMetrics `json:"packages"`
+	Layers   []componentMetrics `json:"layers"`
+	Domains  []componentMetrics `json:"domains"`
+	Worst    []componentMetrics `json:"worst"`
+}
+
+// packageMetrics computes the metrics of every package, of every layer of
+// every domain and of every domain, optionally within a single domain, and
+// ranks the packages farthest from the main sequence. A group's couplings
+// count the packages outside the group, so dependencies between the
+// packages of one layer do not make the layer less stable.
+func packageMetrics(graph *importGraph, rules *archRules, domain string, top int) *metricsReport {
+	typed := graph.typedPackages()
+
+	imports := make(map[string]map[string]bool)
+	graph.eachInternalImport(func(pkg *packageNode, _ importRef, target string) {
+		if imports[pkg.relPath] == nil {
+			imports[pkg.relPath] = make(map[string]bool)
+		}
+		imports[pkg.relPath][target] = true
+	})
+
+	type group struct {
+		name, domain, layer string
+		members             map[string]bool
+	}
+	var pkgs, layers, domains []*group
+	byLayer := make(map[[2]string]*group)
+	byDomain := make(map[string]*group)
+	for _, pkg := range graph.sortedPackages() {
+		d, l := rules.classify(pkg.relPath)
+		if domain != "" && d != domain {
+			continue
+		}
+		pkgs = append(pkgs, &group{pkg.relPath, d, l, map[string]bool{pkg.relPath: true}})
+		if l != "" {
+			key := [2]string{d, l}
+			if byLayer[key] == nil {
+				name := l
+				if d != "" {
+					name = d + "/" + l
+				}
+				byLayer[key] = &group{name, d, l, make(map[string]bool)}
+				layers = append(layers, byLayer[key])
+			}
+			byLayer[key].members[pkg.relPath] = true
+		}
+		// A domain also counts its packages that fit no layer.
+		if d == "" {
+			continue
+		}
+		if byDomain[d] == nil {
+			byDomain[d] = &group{d, d, "", make(map[string]bool)}
+			domains = append(domains, byDomain[d])
+		}
+		byDomain[d].members[pkg.relPath] = true
+	}
+
+	measure := func(g *group) componentMetrics {
+		m := componentMetrics{Name: g.name, Domain: g.domain, Layer: g.layer, Packages: len(g.members)}
+		afferent := make(map[string]bool)
+		efferent := make(map[string]bool)
+		for from, targets := range imports {
+			for to := range targets {
+				switch {
+				case g.members[from] && !g.members[to]:
+					efferent[to] = true
+				case !g.members[from] && g.members[to]:
+					afferent[from] = true
+				}
+			}
+		}
+		m.Afferent, m.Efferent = len(afferent), len(efferent)
+
+		for rel := range g.members {
+			for _, tn := range namedTypes(typed[graph.importPathOf(rel)]) {
+				m.Types++
+				if isInterface(tn) {
+					m.Interfaces++
+				}
+			}
+		}
+
+		if m.Afferent+m.Efferent > 0 {
+			m.Instability = float64(m.Efferent) / float64(m.Afferent+m.Efferent)
+		}
+		if m.Types > 0 {
+			m.Abstractness = float64(m.Interfaces) / float64(m.Types)
+		}
+		m.Distance = math.Abs(m.Abstractness + m.Instability - 1)
+		if m.Distance > zoneDistance && m.Types > 0 {
+			if m.Abstractness+m.Instability < 1 {
+				m.Zone = zonePain
+			} else {
+				m.Zone = zoneUselessness
+			}
+		}
+		m.Instability = round3(m.Instability)
+		m.Abstractness = round3(m.Abstractness)
+		m.Distance = round3(m.Distance)
+		return m
+	}
+
+	report := &metricsReport{
+		Check:    "package_metrics",
+		Packages: []componentMetrics{},
+		Layers:   []componentMetrics{},
+		Domains:  []componentMetrics{},
+		Worst:    []componentMetrics{},
+	}
+	for _, g := range pkgs {
+		report.Packages = append(report.Packages, measure(g))
+	}
+	for _, g := range layers {
+		report.Layers = append(report.Layers, measure(g))
+	}
+	for _, g := range domains {
+		report.Domains = append(report.Domains, measure(g))
+	}
+	slices.SortFunc(report.Layers, func(a, b componentMetrics) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(report.Domains, func(a, b componentMetrics) int { return cmp.Compare(a.Name, b.Name) })
+
+	// Abstractness means nothing for packages that declare no types.
+	for _, m := range report.Packages {
+		if m.Distance > 0 && m.Types > 0 {
+			report.Worst = append(report.Worst, m)
+		}
+	}
+	slices.SortStableFunc(report.Worst, func(a, b componentMetrics) int {
+		if c := cmp.Compare(b.Distance, a.Distance); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Afferent+b.Efferent, a.Afferent+a.Efferent)
+	})
+	if top > 0 && len(report.Worst) > top {
+		report.Worst = report.Worst[:top]
+	}
+	return report
+}
+
+func round3(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
+
+func (m componentMetrics) String() string {
+	s := fmt.Sprintf("D=%.2f I=%.2f A=%.2f Ca=%d Ce=%d types=%d interfaces=%d",
+		m.Distance, m.Instability, m.Abstractness, m.Afferent, m.Efferent, m.Types, m.Interfaces)
+	switch m.Zone {
+	case zonePain:
+		s += " ⚠️ zone of pain: stable and concrete, hard to change"
+	case zoneUselessness:
+		s += " ⚠️ zone of uselessness: abstract but nothing depends on it"
+	}
+	return s
+}
+
+func (r *metricsReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Package metrics for %d package(s)\n", len(r.Packages))
+	b.WriteString("Ca/Ce: module packages depending on / depended on; I = Ce/(Ca+Ce); A = interfaces/types; D = |A+I-1|\n")
+
+	if len(r.Worst) == 0 {
+		b.WriteString("\n✅ Every package lies on the main sequence\n")
+	} else {
+		b.WriteString("\nFarthest from the main sequence:\n")
+		for i, m := range r.Worst {
+			fmt.Fprintf(&b, "  %2d. %s  %s\n", i+1, m.Name, m)
+		}
+	}
+
+	if len(r.Domains) > 0 {
+		b.WriteString("\nDomains:\n")
+		for _, m := range r.Domains {
+			fmt.Fprintf(&b, "  %s (%d package(s))  %s\n", m.Name, m.Packages, m)
+		}
+	}
+	if len(r.Layers) > 0 {
+		b.WriteString("\nLayers:\n")
+		for _, m := range r.Layers {
+			fmt.Fprintf(&b, "  %s (%d package(s))  %s\n", m.Name, m.Packages, m)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+var metricsModule = map[string]string{
+	"internal/order/domain/order.go": `package domain
+
+type Order struct{}
+
+type Repository interface{ Save(Order) error }
+`,
+	"internal/order/application/service.go": `package application
+
+import "example.com/shop/internal/order/domain"
+
+type Service struct{ repo domain.Repository }
+`,
+	"internal/order/events/events.go": `package events
+
+import "example.com/shop/internal/order/domain"
+
+type Placed struct{ Order domain.Order }
+`,
+	"internal/billing/domain/invoice.go": `package domain
+
+import order "example.com/shop/internal/order/domain"
+
+type Invoice struct{ Order order.Order }
+`,
+	"cmd/app/main.go": `package main
+
+import (
+	_ "example.com/shop/internal/billing/domain"
+	_ "example.com/shop/internal/order/application"
+)
+
+func main() {}
+`,
+}
+
+func TestPackageMetrics(t *testing.T) {
+	type metrics struct {
+		packages, ca, ce, types, interfaces int
+		i, a, d                             float64
+		zone                                string
+	}
+	measured := func(m componentMetrics) metrics {
+		return metrics{m.Packages, m.Afferent, m.Efferent, m.Types, m.Interfaces, m.Instability, m.Abstractness, m.Distance, m.Zone}
+	}
+
+	tests := []struct {
+		name   string
+		domain string
+		top    int
+		want   map[string]metrics
+		worst  []string
+	}{
+		{
+			name: "whole module",
+			want: map[string]metrics{
+				"package internal/order/domain":      {1, 3, 0, 2, 1, 0, 0.5, 0.5, ""},
+				"package internal/order/application": {1, 1, 1, 1, 0, 0.5, 0, 0.5, ""},
+				"package internal/order/events":      {1, 0, 1, 1, 0, 1, 0, 0, ""},
+				"package internal/billing/domain":    {1, 1, 1, 1, 0, 0.5, 0, 0.5, ""},
+				"package cmd/app":                    {1, 0, 2, 0, 0, 1, 0, 0, ""},
+				"layer order/domain":                 {1, 3, 0, 2, 1, 0, 0.5, 0.5, ""},
+				"layer order/application":            {1, 1, 1, 1, 0, 0.5, 0, 0.5, ""},
+				"layer billing/domain":               {1, 1, 1, 1, 0, 0.5, 0, 0.5, ""},
+				// The events package fits no layer but belongs to the domain.
+				"domain order":   {3, 2, 0, 4, 1, 0, 0.25, 0.75, zonePain},
+				"domain billing": {1, 1, 1, 1, 0, 0.5, 0, 0.5, ""},
+			},
+			worst: []string{"internal/order/domain", "internal/billing/domain", "internal/order/application"},
+		},
+		{
+			name:   "single domain keeps couplings to the rest of the module",
+			domain: "billing",
+			want: map[string]metrics{
+				"package internal/billing/domain": {1, 1, 1, 1, 0, 0.5, 0, 0.5, ""},
+				"layer billing/domain":            {1, 1, 1, 1, 0, 0.5, 0, 0.5, ""},
+				"domain billing":                  {1, 1, 1, 1, 0, 0.5, 0, 0.5, ""},
+			},
+			worst: []string{"internal/billing/domain"},
+		},
+		{
+			name:  "top limits the ranking",
+			top:   1,
+			want:  nil,
+			worst: []string{"internal/order/domain"},
+		},
+	}
+
+	graph := loadModule(t, metricsModule)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := packageMetrics(graph, defaultRules(), tt.domain, tt.top)
+
+			got := make(map[string]metrics)
+			for kind, components := range map[string][]componentMetrics{"package": report.Packages, "layer": report.Layers, "domain": report.Domains} {
+				for _, m := range components {
+					got[kind+" "+m.Name] = measured(m)
+				}
+			}
+			if tt.want != nil {
+				for name, want := range tt.want {
+					if got[name] != want {
+						t.Errorf("%s = %+v, want %+v", name, got[name], want)
+					}
+				}
+				if len(got) != len(tt.want) {
+					t.Errorf("got %d components, want %d: %v", len(got), len(tt.want), got)
+				}
+			}
+
+			var worst []string
+			for _, m := range report.Worst {
+				worst = append(worst, m.Name)
+			}
+			if !slices.Equal(worst, tt.worst) {
+				t.Errorf("worst = %q, want %q", worst, tt.worst)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const ruleModuleDeps = "module-deps"
+
+// moduleViolations reports imports of other modules of the go.work
+// workspace that the first module rule covering the analyzed module does
+// not allow. Modules no rule covers may import any workspace module.
+func moduleViolations(graph *importGraph, rules *archRules) []violation {
+	rule, ok := rules.moduleRule(graph.modulePath)
+	if !ok || len(rules.workspace) == 0 {
+		return nil
+	}
+
+	var violations []violation
+	for _, pkg := range graph.sortedPackages() {
+		for _, f := range pkg.files {
+			for _, imp := range f.imports {
+				target := workspaceModuleOf(graph, rules, imp.path)
+				if target == "" || rule.allows(target) {
+					continue
+				}
+
+				message := fmt.Sprintf("module %s must not import workspace module %s", graph.modulePath, target)
+				fix := fmt.Sprintf("Remove the dependency on %s", target)
+				if len(rule.Allow) > 0 {
+					message += ", only " + strings.Join(rule.Allow, ", ")
+					fix = fmt.Sprintf("Move what %s needs from %s into %s and import it from there", pkg.relPath, target, strings.Join(rule.Allow, " or "))
+				}
+				if rule.Reason != "" {
+					message += " (" + rule.Reason + ")"
+				}
+				violations = append(violations, violation{
+					Rule:     ruleModuleDeps,
+					Severity: severityError,
+					Package:  pkg.importPath,
+					Import:   imp.path,
+					File:     imp.file,
+					Line:     imp.line,
+					Message:  message,
+					Fix:      fix,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// workspaceImports lists the other workspace modules the analyzed module
+// imports.
+func workspaceImports(graph *importGraph, rules *archRules) []string {
+	modules := []string{}
+	for _, pkg := range graph.packages {
+		for _, f := range pkg.files {
+			for _, imp := range f.imports {
+				if target := workspaceModuleOf(graph, rules, imp.path); target != "" && !slices.Contains(modules, target) {
+					modules = append(modules, target)
+				}
+			}
+		}
+	}
+	slices.Sort(modules)
+	return modules
+}
+
+// workspaceModuleOf returns the workspace module other than the analyzed one
+// that provides importPath, or "". The longest module path wins, so modules
+// nested in the analyzed one count as other modules.
+func workspaceModuleOf(graph *importGraph, rules *archRules, importPath string) string {
+	if module := requiredModule(rules.workspace, importPath); module != graph.modulePath {
+		return module
+	}
+	return ""
+}
+
+// allows reports whether a module covered by m may import the workspace
+// module modulePath.
+func (m moduleRule) allows(modulePath string) bool {
+	for _, a := range m.Allow {
+		if _, ok := matchPath(a, modulePath); ok {
+			return true
+		}
+	}
+	return false
+}
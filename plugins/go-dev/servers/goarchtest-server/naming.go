@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// Kinds of type declarations a naming rule can require.
+const (
+	kindInterface = "interface"
+	kindStruct    = "struct"
+)
+
+// namingViolations checks the exported type declarations of the packages
+// covered by rule: types ending with the suffix must be of the rule's kind
+// and declare its methods, types of the kind that declare all the methods
+// must end with the suffix, and with rule.Constructor each of them needs a
+// New<Type> constructor. Every violation points at the declaration. With
+// rule.Required, a package the rule's path names that declares no type
+// ending with the suffix violates the rule as a whole.
+func namingViolations(graph *importGraph, rules *archRules, rule namingRule) []violation {
+	var violations []violation
+	for _, pkg := range graph.sortedPackages() {
+		if _, layer := rules.classify(pkg.relPath); layer != rule.Layer {
+			continue
+		}
+		if rule.Path != "" {
+			if _, ok := matchPath(rule.Path, pkg.relPath); !ok {
+				continue
+			}
+		}
+
+		newViolation := func(f *sourceFile, pos token.Pos, message, fix string) violation {
+			return violation{
+				Rule:     ruleNaming,
+				Severity: severityError,
+				Package:  pkg.importPath,
+				File:     f.relPath,
+				Line:     graph.fset.Position(pos).Line,
+				Message:  message,
+				Fix:      fix,
+			}
+		}
+
+		found := false
+		var subjects []string
+		pkg.eachTypeSpec(func(f *sourceFile, spec *ast.TypeSpec) {
+			name := spec.Name.Name
+			hasSuffix := strings.HasSuffix(name, rule.Suffix)
+			found = found || hasSuffix
+			if !spec.Name.IsExported() {
+				return
+			}
+
+			kind := typeKind(spec)
+			if hasSuffix && rule.Kind != "" && kind != "" && kind != rule.Kind {
+				violations = append(violations, newViolation(f, spec.Pos(),
+					fmt.Sprintf("%s is declared as %s, but %s types must be %ss", name, kind, rule.Name, rule.Kind),
+					fmt.Sprintf("Declare %s as a %s, or rename it so that it does not end with %q", name, rule.Kind, rule.Suffix),
+				))
+				return
+			}
+			if rule.Kind != "" && kind != rule.Kind {
+				return
+			}
+
+			declared := declaredMethods(pkg, spec)
+			var missing []string
+			for _, m := range rule.Methods {
+				if !slices.Contains(declared, m) {
+					missing = append(missing, m)
+				}
+			}
+
+			switch {
+			case hasSuffix && len(missing) > 0:
+				violations = append(violations, newViolation(f, spec.Pos(),
+					fmt.Sprintf("%s does not declare %s", name, strings.Join(missing, ", ")),
+					fmt.Sprintf("Give %s the %s method(s) every %s exposes", name, strings.Join(missing, ", "), rule.Name),
+				))
+			case !hasSuffix && len(rule.Methods) > 0 && len(missing) == 0:
+				violations = append(violations, newViolation(f, spec.Pos(),
+					fmt.Sprintf("%s declares %s but its name does not end with %q", name, strings.Join(rule.Methods, ", "), rule.Suffix),
+					fmt.Sprintf("Rename %s to %s%s", name, name, rule.Suffix),
+				))
+			case !hasSuffix:
+				return
+			}
+			// Interfaces have no constructors.
+			if kind != kindInterface {
+				subjects = append(subjects, name)
+			}
+		})
+
+		if rule.Constructor {
+			violations = append(violations, constructorViolations(pkg, subjects, newViolation)...)
+		}
+
+		if rule.Required && !found && matchPackage(rule.Path, pkg.relPath) {
+			violations = append(violations, violation{
+				Rule:     ruleNaming,
+				Severity: severityError,
+				Package:  pkg.importPath,
+				Message:  fmt.Sprintf("no type name ends with %q", rule.Suffix),
+				Fix:      fmt.Sprintf("Rename the %s type of this package to end with %q", rule.Name, rule.Suffix),
+			})
+		}
+	}
+	return violations
+}
+
+// constructorViolations requires a constructor for each of types: an
+// exported function that returns the type and is named New<Type>, or a
+// variant such as New<Type>WithClock. Other functions returning the type,
+// such as Parse<Type>, are not constructors and are left alone.
+func constructorViolations(pkg *packageNode, types []string, newViolation func(*sourceFile, token.Pos, string, string) violation) []violation {
+	var violations []violation
+	constructed := make(map[string]bool)
+	for _, f := range pkg.files {
+		for _, decl := range f.ast.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !fn.Name.IsExported() {
+				continue
+			}
+			if typeName := constructedType(fn); slices.Contains(types, typeName) && strings.HasPrefix(fn.Name.Name, "New"+typeName) {
+				constructed[typeName] = true
+			}
+		}
+	}
+
+	for _, typeName := range types {
+		if constructed[typeName] {
+			continue
+		}
+		pkg.eachTypeSpec(func(f *sourceFile, spec *ast.TypeSpec) {
+			if spec.Name.Name == typeName {
+				violations = append(violations, newViolation(f, spec.Pos(),
+					fmt.Sprintf("%s has no New%s constructor", typeName, typeName),
+					fmt.Sprintf("Add func New%s that takes the dependencies of %s and returns *%s", typeName, typeName, typeName),
+				))
+			}
+		})
+	}
+	return violations
+}
+
+// typeKind returns "interface" or "struct" for declarations of those kinds,
+// the literal kind for other type literals and "" for types defined in
+// terms of another named type, whose kind cannot be told from the
+// declaration alone.
+func typeKind(spec *ast.TypeSpec) string {
+	switch t := spec.Type.(type) {
+	case *ast.InterfaceType:
+		return kindInterface
+	case *ast.StructType:
+		return kindStruct
+	case *ast.FuncType:
+		return "func"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return "array"
+		}
+		return "slice"
+	case *ast.ChanType:
+		return "chan"
+	}
+	return ""
+}
+
+// declaredMethods returns the methods of an interface declaration, or the
+// methods pkg declares on any other type.
+func declaredMethods(pkg *packageNode, spec *ast.TypeSpec) []string {
+	iface, ok := spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return pkg.methods(spec.Name.Name)
+	}
+	var names []string
+	for _, m := range iface.Methods.List {
+		for _, n := range m.Names {
+			names = append(names, n.Name)
+		}
+	}
+	return names
+}
+
+// constructedType returns the name of the package-local type a function
+// returns first, as T or *T, or "".
+func constructedType(fn *ast.FuncDecl) string {
+	if fn.Type.Results == nil || len(fn.Type.Results.List) == 0 {
+		return ""
+	}
+	expr := fn.Type.Results.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+)
+
+func TestNamingViolations(t *testing.T) {
+	usecase := namingRule{Name: "usecase", Layer: "application", Path: "internal/*/application/usecase", Suffix: "UseCase", Kind: kindStruct, Methods: []string{"Execute"}, Constructor: true}
+	repository := namingRule{Name: "repository", Layer: "domain", Suffix: "Repository", Kind: kindInterface}
+
+	tests := []struct {
+		name  string
+		rule  namingRule
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "conforming use case",
+			rule: usecase,
+			files: map[string]string{"internal/order/application/usecase/place.go": `package usecase
+
+type PlaceOrderUseCase struct{}
+
+func NewPlaceOrderUseCase() *PlaceOrderUseCase { return &PlaceOrderUseCase{} }
+
+func (u *PlaceOrderUseCase) Execute() error { return nil }
+`},
+		},
+		{
+			name: "wrong kind",
+			rule: usecase,
+			files: map[string]string{"internal/order/application/usecase/place.go": `package usecase
+
+type PlaceOrderUseCase interface{ Execute() error }
+`},
+			want: []string{"PlaceOrderUseCase is declared as interface, but usecase types must be structs"},
+		},
+		{
+			name: "missing method and constructor",
+			rule: usecase,
+			files: map[string]string{"internal/order/application/usecase/place.go": `package usecase
+
+type PlaceOrderUseCase struct{}
+`},
+			want: []string{
+				"PlaceOrderUseCase does not declare Execute",
+				"PlaceOrderUseCase has no NewPlaceOrderUseCase constructor",
+			},
+		},
+		{
+			name: "methods without the suffix",
+			rule: usecase,
+			files: map[string]string{"internal/order/application/usecase/place.go": `package usecase
+
+type PlaceOrder struct{}
+
+func NewPlaceOrder() *PlaceOrder { return nil }
+
+func (PlaceOrder) Execute() error { return nil }
+`},
+			want: []string{`PlaceOrder declares Execute but its name does not end with "UseCase"`},
+		},
+		{
+			name: "constructor variants and other functions returning the type",
+			rule: usecase,
+			files: map[string]string{"internal/order/application/usecase/place.go": `package usecase
+
+type PlaceOrderUseCase struct{}
+
+type CancelOrderUseCase struct{}
+
+func NewPlaceOrderUseCaseWithClock() *PlaceOrderUseCase { return nil }
+
+func ParseCancelOrderUseCase() CancelOrderUseCase { return CancelOrderUseCase{} }
+
+func (PlaceOrderUseCase) Execute() error  { return nil }
+func (CancelOrderUseCase) Execute() error { return nil }
+`},
+			want: []string{"CancelOrderUseCase has no NewCancelOrderUseCase constructor"},
+		},
+		{
+			name: "outside the rule's path",
+			rule: usecase,
+			files: map[string]string{"internal/order/application/service.go": `package application
+
+type PlaceOrderUseCase interface{}
+`},
+		},
+		{
+			name: "unexported and other-kind types are ignored",
+			rule: repository,
+			files: map[string]string{"internal/order/domain/order.go": `package domain
+
+type orderRepository struct{}
+
+type Orders []string
+
+type OrderRepository interface{ Save() error }
+`},
+		},
+		{
+			name: "type defined from another type has no known kind",
+			rule: repository,
+			files: map[string]string{"internal/order/domain/order.go": `package domain
+
+type Base interface{}
+
+type OrderRepository Base
+`},
+		},
+		{
+			name: "required suffix in the named package only",
+			rule: namingRule{Name: "repository", Layer: "domain", Path: "internal/order/domain", Suffix: "Repository", Required: true},
+			files: map[string]string{
+				"internal/order/domain/order.go":       "package domain\n\ntype Order struct{}\n",
+				"internal/order/domain/model/model.go": "package model\n\ntype Line struct{}\n",
+			},
+			want: []string{`no type name ends with "Repository"`},
+		},
+		{
+			name: "missing suffix is not reported unless required",
+			rule: namingRule{Name: "repository", Layer: "domain", Path: "internal/order/domain", Suffix: "Repository"},
+			files: map[string]string{
+				"internal/order/domain/order.go": "package domain\n\ntype Order struct{}\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := loadModule(t, tt.files)
+			var got []string
+			for _, v := range namingViolations(graph, defaultRules(), tt.rule) {
+				if v.Rule != ruleNaming || v.Severity != severityError {
+					t.Errorf("violation %q has rule %s and severity %s", v.Message, v.Rule, v.Severity)
+				}
+				got = append(got, v.Message)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeKind(t *testing.T) {
+	const src = `package p
+
+type (
+	I interface{}
+	S struct{}
+	F func()
+	M map[string]int
+	L []int
+	A [4]int
+	C chan int
+	N S
+	P *S
+)
+`
+	want := map[string]string{"I": "interface", "S": "struct", "F": "func", "M": "map", "L": "slice", "A": "array", "C": "chan", "N": "", "P": ""}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range f.Decls[0].(*ast.GenDecl).Specs {
+		spec := spec.(*ast.TypeSpec)
+		if got := typeKind(spec); got != want[spec.Name.Name] {
+			t.Errorf("typeKind(%s) = %q, want %q", spec.Name.Name, got, want[spec.Name.Name])
+		}
+	}
+}
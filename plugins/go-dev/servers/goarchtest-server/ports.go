@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const rulePorts = "ports"
+
+// portInfo is a port of a domain together with the adapters implementing it.
+type portInfo struct {
+	Domain   string   `json:"domain"`
+	Name     string   `json:"name"`
+	Package  string   `json:"package"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Adapters []string `json:"adapters"`
+}
+
+// portSet is what the port check knows about one domain: the interfaces of
+// its inner layers and the concrete types of its outer layers.
+type portSet struct {
+	ports    []*types.TypeName
+	adapters []*types.TypeName
+}
+
+// portViolations checks every domain, or only domain, for ports and
+// adapters that do not fit together. Ports are the interfaces declared in
+// the inner layers and adapters the concrete types of the outer layers, as
+// resolved by go/types. It reports ports no adapter of the domain
+// implements, adapters that declare most methods of a port but implement
+// none, and types of the layers in between, the use cases, whose fields or
+// function parameters are concrete outer-layer types instead of ports.
+func portViolations(graph *importGraph, rules *archRules, domain string) ([]portInfo, []violation) {
+	typed := graph.typedPackages()
+	inner, outer := rules.innerLayers(), rules.outerLayers()
+
+	sets := make(map[string]*portSet)
+	var middle []*packageNode
+	for _, pkg := range graph.sortedPackages() {
+		d, layer := rules.classify(pkg.relPath)
+		if d == "" || (domain != "" && d != domain) {
+			continue
+		}
+		if sets[d] == nil {
+			sets[d] = &portSet{}
+		}
+		for _, tn := range namedTypes(typed[pkg.importPath]) {
+			if isGeneric(tn) {
+				continue
+			}
+			switch {
+			case slices.Contains(inner, layer):
+				if iface, ok := tn.Type().Underlying().(*types.Interface); ok && tn.Exported() && iface.IsMethodSet() && iface.NumMethods() > 0 {
+					sets[d].ports = append(sets[d].ports, tn)
+				}
+			case slices.Contains(outer, layer):
+				if !isInterface(tn) {
+					sets[d].adapters = append(sets[d].adapters, tn)
+				}
+			}
+		}
+		if layer != "" && !slices.Contains(inner, layer) && !slices.Contains(outer, layer) {
+			middle = append(middle, pkg)
+		}
+	}
+
+	ports := []portInfo{}
+	var violations []violation
+	for _, d := range slices.Sorted(maps.Keys(sets)) {
+		set := sets[d]
+		implemented := make(map[*types.TypeName]bool)
+
+		for _, port := range set.ports {
+			iface := port.Type().Underlying().(*types.Interface)
+			file, line := graph.position(port.Pos())
+			info := portInfo{Domain: d, Name: port.Name(), Package: port.Pkg().Path(), File: file, Line: line, Adapters: []string{}}
+			for _, adapter := range set.adapters {
+				if implements(adapter, iface) {
+					implemented[adapter] = true
+					info.Adapters = append(info.Adapters, qualifiedName(graph, adapter))
+				}
+			}
+			ports = append(ports, info)
+
+			if len(info.Adapters) == 0 {
+				violations = append(violations, violation{
+					Rule:     rulePorts,
+					Severity: severityWarning,
+					Package:  info.Package,
+					File:     file,
+					Line:     line,
+					Message:  fmt.Sprintf("port %s has no adapter in the %s layer of %s", port.Name(), strings.Join(outer, ", "), d),
+					Fix:      fmt.Sprintf("Implement %s in an adapter of the %s domain, or remove the port if nothing needs it", port.Name(), d),
+				})
+			}
+		}
+
+		for _, adapter := range set.adapters {
+			if implemented[adapter] {
+				continue
+			}
+			port, problem := closestPort(adapter, set.ports)
+			if port == nil {
+				continue
+			}
+			file, line := graph.position(adapter.Pos())
+			violations = append(violations, violation{
+				Rule:     rulePorts,
+				Severity: severityWarning,
+				Package:  adapter.Pkg().Path(),
+				File:     file,
+				Line:     line,
+				Message:  fmt.Sprintf("adapter %s implements no port of %s; closest port %s: %s", adapter.Name(), d, port.Name(), problem),
+				Fix:      fmt.Sprintf("Match the method set of %s, or rename the methods %s shares with it", qualifiedName(graph, port), adapter.Name()),
+			})
+		}
+	}
+
+	for _, pkg := range middle {
+		d, _ := rules.classify(pkg.relPath)
+		violations = append(violations, concreteDependencyViolations(graph, rules, typed[pkg.importPath], sets[d])...)
+	}
+	return ports, violations
+}
+
+// concreteDependencyViolations reports struct fields and exported function
+// parameters of pkg whose type is a concrete type of an outer layer.
+func concreteDependencyViolations(graph *importGraph, rules *archRules, pkg *types.Package, set *portSet) []violation {
+	if pkg == nil {
+		return nil
+	}
+	outer := rules.outerLayers()
+
+	var violations []violation
+	check := func(owner string, v *types.Var) {
+		named := concreteNamed(v.Type())
+		if named == nil || named.Obj().Pkg() == nil {
+			return
+		}
+		rel, ok := graph.relImport(named.Obj().Pkg().Path())
+		if !ok {
+			return
+		}
+		if _, layer := rules.classify(rel); !slices.Contains(outer, layer) {
+			return
+		}
+
+		fix := fmt.Sprintf("Declare a port for what %s needs in the %s layer and depend on it instead", owner, strings.Join(rules.innerLayers(), ", "))
+		if set != nil {
+			var implemented []string
+			for _, port := range set.ports {
+				if implements(named.Obj(), port.Type().Underlying().(*types.Interface)) {
+					implemented = append(implemented, qualifiedName(graph, port))
+				}
+			}
+			if len(implemented) > 0 {
+				fix = fmt.Sprintf("Depend on the port %s instead", strings.Join(implemented, " or "))
+			}
+		}
+
+		file, line := graph.position(v.Pos())
+		violations = append(violations, violation{
+			Rule:     rulePorts,
+			Severity: severityError,
+			Package:  pkg.Path(),
+			File:     file,
+			Line:     line,
+			Message:  fmt.Sprintf("%s depends on concrete adapter %s through %s", owner, qualifiedName(graph, named.Obj()), v.Name()),
+			Fix:      fix,
+		})
+	}
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		switch obj := scope.Lookup(name).(type) {
+		case *types.TypeName:
+			if st, ok := obj.Type().Underlying().(*types.Struct); ok && !obj.IsAlias() {
+				for f := range st.Fields() {
+					check(obj.Name(), f)
+				}
+			}
+		case *types.Func:
+			if !obj.Exported() {
+				continue
+			}
+			for p := range obj.Signature().Params().Variables() {
+				check(obj.Name(), p)
+			}
+		}
+	}
+	return violations
+}
+
+// closestPort returns the port sharing the most method names with adapter,
+// and what keeps adapter from implementing it. Only ports whose methods
+// adapter mostly declares count, and it returns nil when there is none, as
+// most outer-layer types, such as HTTP handlers with a FindByID method, are
+// no adapters.
+func closestPort(adapter *types.TypeName, ports []*types.TypeName) (*types.TypeName, string) {
+	methods := types.NewMethodSet(types.NewPointer(adapter.Type()))
+
+	var best *types.TypeName
+	shared := 0
+	for _, port := range ports {
+		iface := port.Type().Underlying().(*types.Interface)
+		n := 0
+		for m := range iface.Methods() {
+			if methods.Lookup(m.Pkg(), m.Name()) != nil {
+				n++
+			}
+		}
+		if 2*n > iface.NumMethods() && n > shared {
+			best, shared = port, n
+		}
+	}
+	if best == nil {
+		return nil, ""
+	}
+
+	missing, wrongType := types.MissingMethod(types.NewPointer(adapter.Type()), best.Type().Underlying().(*types.Interface), true)
+	if wrongType {
+		return best, "wrong signature for " + missing.Name()
+	}
+	return best, "missing method " + missing.Name()
+}
+
+// implements reports whether tn, or a pointer to it, implements iface.
+func implements(tn *types.TypeName, iface *types.Interface) bool {
+	return types.Implements(tn.Type(), iface) || types.Implements(types.NewPointer(tn.Type()), iface)
+}
+
+// concreteNamed returns the named non-interface type t refers to, directly,
+// through a pointer or as the element of a slice, or nil.
+func concreteNamed(t types.Type) *types.Named {
+	for {
+		switch u := t.(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Named:
+			if types.IsInterface(u) {
+				return nil
+			}
+			return u
+		default:
+			return nil
+		}
+	}
+}
+
+func isGeneric(tn *types.TypeName) bool {
+	named, ok := tn.Type().(*types.Named)
+	return ok && named.TypeParams().Len() > 0
+}
+
+// qualifiedName names tn by its module-relative package path.
+func qualifiedName(graph *importGraph, tn *types.TypeName) string {
+	if rel, ok := graph.relImport(tn.Pkg().Path()); ok {
+		return rel + "." + tn.Name()
+	}
+	return tn.Pkg().Path() + "." + tn.Name()
+}
+
+// position returns the module-relative file and the line of pos.
+func (g *importGraph) position(pos token.Pos) (string, int) {
+	p := g.fset.Position(pos)
+	rel, err := filepath.Rel(g.root, p.Filename)
+	if err != nil {
+		return p.Filename, p.Line
+	}
+	return filepath.ToSlash(rel), p.Line
+}
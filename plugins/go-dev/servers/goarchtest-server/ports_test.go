@@ -0,0 +1,171 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+var portsModule = map[string]string{
+	"internal/user/domain/user.go": `package domain
+
+import "context"
+
+type User struct{ ID string }
+
+type UserRepository interface {
+	Save(ctx context.Context, u *User) error
+	Find(ctx context.Context, id string) (*User, error)
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, u *User) error
+}
+
+type Clock interface {
+	Now() int64
+}
+
+type Store[T any] interface {
+	Put(T) error
+}
+
+type marker interface{ mark() }
+`,
+	"internal/user/infrastructure/persistence/repo.go": `package persistence
+
+import (
+	"context"
+
+	"example.com/shop/internal/user/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type PostgresUserRepository struct{ conn *pgx.Conn }
+
+func (r *PostgresUserRepository) Save(ctx context.Context, u *domain.User) error { return nil }
+
+func (r *PostgresUserRepository) Find(ctx context.Context, id string) (*domain.User, error) {
+	return nil, nil
+}
+`,
+	"internal/user/infrastructure/mail/mail.go": `package mail
+
+import "example.com/shop/internal/user/domain"
+
+type SMTPNotifier struct{}
+
+func (SMTPNotifier) Notify(u *domain.User) error { return nil }
+`,
+	"internal/user/infrastructure/http/handler.go": `package http
+
+type UserHandler struct{}
+
+func (UserHandler) Find() {}
+`,
+	"internal/user/application/usecase/create.go": `package usecase
+
+import (
+	"example.com/shop/internal/user/domain"
+	"example.com/shop/internal/user/infrastructure/mail"
+	"example.com/shop/internal/user/infrastructure/persistence"
+)
+
+type CreateUserUseCase struct {
+	repo     *persistence.PostgresUserRepository
+	clock    domain.Clock
+	notifier []mail.SMTPNotifier
+}
+
+func NewCreateUserUseCase(repo *persistence.PostgresUserRepository, clock domain.Clock) *CreateUserUseCase {
+	return &CreateUserUseCase{repo: repo, clock: clock}
+}
+
+func newInternal(repo *persistence.PostgresUserRepository) {}
+`,
+	"internal/billing/domain/invoice.go": `package domain
+
+type Invoices interface {
+	Total() int
+}
+`,
+}
+
+func TestPortViolations(t *testing.T) {
+	graph := loadModule(t, portsModule)
+	ports, violations := portViolations(graph, defaultRules(), "")
+
+	var gotPorts []string
+	for _, p := range ports {
+		gotPorts = append(gotPorts, p.Domain+" "+p.Name+" "+p.File)
+		if p.Name == "UserRepository" && !slices.Equal(p.Adapters, []string{"internal/user/infrastructure/persistence.PostgresUserRepository"}) {
+			t.Errorf("UserRepository adapters = %q", p.Adapters)
+		}
+		if p.Name != "UserRepository" && len(p.Adapters) > 0 {
+			t.Errorf("%s adapters = %q, want none", p.Name, p.Adapters)
+		}
+	}
+	wantPorts := []string{
+		"billing Invoices internal/billing/domain/invoice.go",
+		"user Clock internal/user/domain/user.go",
+		"user Notifier internal/user/domain/user.go",
+		"user UserRepository internal/user/domain/user.go",
+	}
+	slices.Sort(gotPorts)
+	if !slices.Equal(gotPorts, wantPorts) {
+		t.Errorf("ports = %q, want %q", gotPorts, wantPorts)
+	}
+
+	got := make(map[string]string)
+	for _, v := range violations {
+		got[v.Message] = v.Severity + " " + v.File
+		if v.Rule != rulePorts || v.Line == 0 {
+			t.Errorf("violation %q lacks its rule or line", v.Message)
+		}
+	}
+	want := map[string]string{
+		"port Invoices has no adapter in the infrastructure layer of billing":                                                           "warning internal/billing/domain/invoice.go",
+		"port Clock has no adapter in the infrastructure layer of user":                                                                 "warning internal/user/domain/user.go",
+		"port Notifier has no adapter in the infrastructure layer of user":                                                              "warning internal/user/domain/user.go",
+		"adapter SMTPNotifier implements no port of user; closest port Notifier: wrong signature for Notify":                            "warning internal/user/infrastructure/mail/mail.go",
+		"CreateUserUseCase depends on concrete adapter internal/user/infrastructure/persistence.PostgresUserRepository through repo":    "error internal/user/application/usecase/create.go",
+		"CreateUserUseCase depends on concrete adapter internal/user/infrastructure/mail.SMTPNotifier through notifier":                 "error internal/user/application/usecase/create.go",
+		"NewCreateUserUseCase depends on concrete adapter internal/user/infrastructure/persistence.PostgresUserRepository through repo": "error internal/user/application/usecase/create.go",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d violations, want %d: %q", len(got), len(want), got)
+	}
+	for message, where := range want {
+		if got[message] != where {
+			t.Errorf("violation %q = %q, want %q", message, got[message], where)
+		}
+	}
+}
+
+func TestPortViolationsForOneDomain(t *testing.T) {
+	graph := loadModule(t, portsModule)
+	ports, violations := portViolations(graph, defaultRules(), "billing")
+	if len(ports) != 1 || ports[0].Name != "Invoices" {
+		t.Errorf("ports = %+v, want only Invoices", ports)
+	}
+	if len(violations) != 1 || violations[0].Message != "port Invoices has no adapter in the infrastructure layer of billing" {
+		t.Errorf("violations = %+v, want the orphaned Invoices port", violations)
+	}
+}
+
+func TestConcreteDependencyFix(t *testing.T) {
+	graph := loadModule(t, portsModule)
+	_, violations := portViolations(graph, defaultRules(), "user")
+	fixes := make(map[string]string)
+	for _, v := range violations {
+		fixes[v.Message] = v.Fix
+	}
+
+	implemented := "CreateUserUseCase depends on concrete adapter internal/user/infrastructure/persistence.PostgresUserRepository through repo"
+	if want := "Depend on the port internal/user/domain.UserRepository instead"; fixes[implemented] != want {
+		t.Errorf("fix = %q, want %q", fixes[implemented], want)
+	}
+	unmatched := "CreateUserUseCase depends on concrete adapter internal/user/infrastructure/mail.SMTPNotifier through notifier"
+	if want := "Declare a port for what CreateUserUseCase needs in the domain layer and depend on it instead"; fixes[unmatched] != want {
+		t.Errorf("fix = %q, want %q", fixes[unmatched], want)
+	}
+}
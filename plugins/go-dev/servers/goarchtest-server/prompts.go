@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// codeSymbol is a type found in the analyzed source, with the methods it
+// declares or, for interfaces, requires.
+type codeSymbol struct {
+	Name    string
+	Package string
+	File    string
+	Line    int
+	Methods []string
+}
+
+func (c codeSymbol) String() string {
+	s := fmt.Sprintf("- %s (%s:%d)", c.Name, c.File, c.Line)
+	if len(c.Methods) > 0 {
+		s += ": " + strings.Join(c.Methods, ", ")
+	}
+	return s
+}
+
+func (s *GoArchTestServer) setupPrompts() {
+	s.mcpServer.AddPrompt(
+		mcp.NewPrompt("review_domain",
+			mcp.WithPromptDescription("Review one bounded context for hexagonal architecture and DDD compliance, starting from its current violations, ports and use cases"),
+			mcp.WithArgument("domain",
+				mcp.ArgumentDescription("Bounded context to review (e.g., 'user', 'order')"),
+				mcp.RequiredArgument(),
+			),
+			moduleArgument(),
+		),
+		s.reviewDomainPrompt,
+	)
+
+	s.mcpServer.AddPrompt(
+		mcp.NewPrompt("plan_new_usecase",
+			mcp.WithPromptDescription("Plan a new application use case that reuses the domain's existing ports and follows its existing use cases"),
+			mcp.WithArgument("domain",
+				mcp.ArgumentDescription("Bounded context the use case belongs to"),
+				mcp.RequiredArgument(),
+			),
+			mcp.WithArgument("name",
+				mcp.ArgumentDescription("Use case name (e.g., 'CreateUser', 'PlaceOrder')"),
+				mcp.RequiredArgument(),
+			),
+			mcp.WithArgument("description",
+				mcp.ArgumentDescription("Optional: what the use case does"),
+			),
+			moduleArgument(),
+		),
+		s.planNewUseCasePrompt,
+	)
+}
+
+// moduleArgument picks the go.work workspace module of a prompt's domain.
+func moduleArgument() mcp.PromptOption {
+	return mcp.WithArgument("module",
+		mcp.ArgumentDescription("Optional: in a go.work workspace, the module of the domain, by module path or directory (default: the first module that has the domain)"),
+	)
+}
+
+// domainContext loads the analysis for a prompt and checks that domain is a
+// bounded context of the project. In a workspace it returns the server of
+// the first selected module that has the domain.
+func (s *GoArchTestServer) domainContext(domain, module string) (*GoArchTestServer, *importGraph, *archRules, error) {
+	if domain == "" {
+		return nil, nil, nil, fmt.Errorf("domain argument is required")
+	}
+	servers, err := s.moduleServers(module)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var domains []string
+	for _, m := range servers {
+		graph, rules, err := m.analyze()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		found := discoveredDomains(graph, rules)
+		if slices.Contains(found, domain) {
+			return m, graph, rules, nil
+		}
+		domains = append(domains, found...)
+	}
+	slices.Sort(domains)
+	return nil, nil, nil, fmt.Errorf("unknown domain %q, expected one of: %s", domain, strings.Join(slices.Compact(domains), ", "))
+}
+
+func (s *GoArchTestServer) reviewDomainPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	domain := request.Params.Arguments["domain"]
+	m, graph, rules, err := s.domainContext(domain, request.Params.Arguments["module"])
+	if err != nil {
+		return nil, err
+	}
+
+	violations, err := m.domainViolations(graph, rules, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Review the %s bounded context of %s for compliance with hexagonal architecture (ports and adapters) and DDD.\n", domain, graph.modulePath)
+	fmt.Fprintf(&b, "The data below comes from the goarchtest analyzer (rules: %s) and reflects the code as it is now.\n", rules.describe())
+
+	writeDomainStructure(&b, graph, rules, domain)
+	writeSection(&b, "Current violations", formatViolations(violations), "None")
+	writeSection(&b, "Ports (interfaces in the "+strings.Join(rules.innerLayers(), ", ")+" layer)", formatSymbols(domainPorts(graph, rules, domain)), "None found")
+	writeSection(&b, "Use cases", formatSymbols(domainUseCases(graph, rules, domain)), "None found")
+	writeSection(&b, "Dependencies on other domains and shared packages", formatExternalEdges(buildLayerGraph(graph, rules, domain)), "None")
+
+	b.WriteString(`
+## What to do
+
+1. Explain each current violation and propose the concrete fix, quoting file and line.
+2. Check domain purity: entities carry business logic and protect invariants, the domain layer imports only the standard library and its own packages, and repository interfaces (ports) live in the domain layer.
+3. Check the application layer: use cases depend on ports rather than implementations, orchestrate domain objects without holding business rules, and return DTOs.
+4. Check the infrastructure layer: adapters implement the ports, handlers stay thin and delegate to use cases.
+5. Flag dependencies on other domains that should go through a port, a domain event or a shared contract.
+
+Report findings grouped by severity (critical, warning, suggestion), each with the file, the problem and the fix.
+`)
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Architecture review of the %s domain", domain),
+		[]mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(b.String()))},
+	), nil
+}
+
+func (s *GoArchTestServer) planNewUseCasePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	domain := request.Params.Arguments["domain"]
+	name := strings.TrimSuffix(request.Params.Arguments["name"], "UseCase")
+	if name == "" {
+		return nil, fmt.Errorf("name argument is required")
+	}
+	m, graph, rules, err := s.domainContext(domain, request.Params.Arguments["module"])
+	if err != nil {
+		return nil, err
+	}
+
+	violations, err := m.domainViolations(graph, rules, domain)
+	if err != nil {
+		return nil, err
+	}
+	rule := useCaseRule(rules)
+	typeName := name + rule.Suffix
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan a new use case %s in the %s bounded context of %s.\n", typeName, domain, graph.modulePath)
+	if desc := request.Params.Arguments["description"]; desc != "" {
+		fmt.Fprintf(&b, "\nWhat it does: %s\n", desc)
+	}
+
+	useCases := domainUseCases(graph, rules, domain)
+	for _, uc := range useCases {
+		if uc.Name == typeName {
+			fmt.Fprintf(&b, "\n⚠️ %s already exists at %s:%d. Plan the changes to it or pick another name.\n", typeName, uc.File, uc.Line)
+		}
+	}
+
+	writeDomainStructure(&b, graph, rules, domain)
+	writeSection(&b, "Existing ports to reuse", formatSymbols(domainPorts(graph, rules, domain)), "None yet, the plan must add the ports the use case needs")
+	writeSection(&b, "Existing use cases to follow", formatSymbols(useCases), "None yet")
+
+	var layerRules []string
+	if l, ok := rules.layer(rule.Layer); ok {
+		deps := "no other layer"
+		if len(l.DependsOn) > 0 {
+			deps = strings.Join(l.DependsOn, ", ")
+		}
+		layerRules = append(layerRules, fmt.Sprintf("- The %s layer may import: %s", l.Name, deps))
+	}
+	layerRules = append(layerRules, fmt.Sprintf("- Type names in %s must end with %q", placement(rule, domain), rule.Suffix))
+	writeSection(&b, "Rules the use case must follow", strings.Join(layerRules, "\n"), "")
+	writeSection(&b, "Current violations in this domain", formatViolations(violations), "None")
+
+	fmt.Fprintf(&b, `
+## What to plan
+
+1. Inputs and outputs: the input and output DTOs of %[1]s.
+2. Ports: which of the existing ports it uses, and any new port or port method to add in the %[2]s layer. Never depend on infrastructure types.
+3. The %[1]s struct in %[3]s with its port dependencies, a New%[1]s constructor and an Execute(ctx, input) method.
+4. The business workflow, its validations and error cases, keeping business rules in domain entities.
+5. Table-driven unit tests written first, with every port mocked: happy path, validation errors, port errors and business rule violations.
+6. Any adapter or handler changes in the infrastructure layer.
+
+Present the plan as the list of files to create or change with what goes in each. Do not write the code yet.
+`, typeName, strings.Join(rules.innerLayers(), ", "), placement(rule, domain))
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Plan for the %s use case in the %s domain", typeName, domain),
+		[]mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(b.String()))},
+	), nil
+}
+
+// domainViolations returns every violation raised by a package of domain,
+// minus the suppressed ones and the baseline.
+func (s *GoArchTestServer) domainViolations(graph *importGraph, rules *archRules, domain string) ([]violation, error) {
+	_, all := runAllRules(graph, rules)
+	report := newCheckReport("prompt", all)
+	ignoreSuppressed(graph, &report)
+	if err := s.subtractBaseline(graph, rules, &report); err != nil {
+		return nil, err
+	}
+
+	var violations []violation
+	for _, v := range report.Violations {
+		rel, _ := graph.relImport(v.Package)
+		if d, _ := rules.classify(rel); d == domain {
+			violations = append(violations, v)
+		}
+	}
+	return violations, nil
+}
+
+// domainPorts returns the interfaces declared in the inner layers of domain.
+func domainPorts(graph *importGraph, rules *archRules, domain string) []codeSymbol {
+	inner := rules.innerLayers()
+
+	var ports []codeSymbol
+	for _, pkg := range graph.sortedPackages() {
+		if d, l := rules.classify(pkg.relPath); d != domain || !slices.Contains(inner, l) {
+			continue
+		}
+		pkg.eachTypeSpec(func(f *sourceFile, spec *ast.TypeSpec) {
+			iface, ok := spec.Type.(*ast.InterfaceType)
+			if !ok {
+				return
+			}
+			port := codeSymbol{
+				Name:    spec.Name.Name,
+				Package: pkg.relPath,
+				File:    f.relPath,
+				Line:    graph.fset.Position(spec.Pos()).Line,
+			}
+			for _, m := range iface.Methods.List {
+				for _, n := range m.Names {
+					port.Methods = append(port.Methods, n.Name)
+				}
+			}
+			ports = append(ports, port)
+		})
+	}
+	return ports
+}
+
+// domainUseCases returns the types of domain's use case layer that follow
+// the use case naming rule or declare an Execute method.
+func domainUseCases(graph *importGraph, rules *archRules, domain string) []codeSymbol {
+	rule := useCaseRule(rules)
+
+	var useCases []codeSymbol
+	for _, pkg := range graph.sortedPackages() {
+		if d, l := rules.classify(pkg.relPath); d != domain || l != rule.Layer {
+			continue
+		}
+		pkg.eachTypeSpec(func(f *sourceFile, spec *ast.TypeSpec) {
+			methods := pkg.methods(spec.Name.Name)
+			if !strings.HasSuffix(spec.Name.Name, rule.Suffix) && !slices.Contains(methods, "Execute") {
+				return
+			}
+			useCases = append(useCases, codeSymbol{
+				Name:    spec.Name.Name,
+				Package: pkg.relPath,
+				File:    f.relPath,
+				Line:    graph.fset.Position(spec.Pos()).Line,
+				Methods: methods,
+			})
+		})
+	}
+	return useCases
+}
+
+// useCaseRule is the project's "usecase" naming rule, or the default one
+// when the rules file declares none.
+func useCaseRule(rules *archRules) namingRule {
+	if rule, ok := rules.namingRule("usecase"); ok {
+		return rule
+	}
+	rule, _ := defaultRules().namingRule("usecase")
+	return rule
+}
+
+// placement describes where a naming rule applies within domain. The
+// domain stands in for both the {domain} placeholder and wildcards of the
+// rule's path.
+func placement(rule namingRule, domain string) string {
+	if rule.Path != "" {
+		return strings.NewReplacer(domainPlaceholder, domain, "*", domain).Replace(rule.Path)
+	}
+	return "the " + rule.Layer + " layer"
+}
+
+func writeDomainStructure(b *strings.Builder, graph *importGraph, rules *archRules, domain string) {
+	var lines []string
+	for _, d := range discoverArchitecture(graph, rules).Domains {
+		if d.Name != domain {
+			continue
+		}
+		for _, l := range d.Layers {
+			lines = append(lines, fmt.Sprintf("- %s layer (%d files)", l.Name, l.Files))
+			for _, p := range l.Packages {
+				lines = append(lines, fmt.Sprintf("  - %s (%d files)", p.Path, p.Files))
+			}
+		}
+	}
+	writeSection(b, "Structure", strings.Join(lines, "\n"), "No packages match the configured layers")
+}
+
+func writeSection(b *strings.Builder, title, body, empty string) {
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	if body == "" {
+		body = empty
+	}
+	b.WriteString(body + "\n")
+}
+
+func formatSymbols(symbols []codeSymbol) string {
+	lines := make([]string, len(symbols))
+	for i, sym := range symbols {
+		lines[i] = sym.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatExternalEdges lists the edges of lg that cross a domain boundary.
+func formatExternalEdges(lg *layerGraph) string {
+	domainOf := make(map[string]string, len(lg.Nodes))
+	for _, n := range lg.Nodes {
+		domainOf[n.ID] = n.Domain
+	}
+
+	var lines []string
+	for _, e := range lg.Edges {
+		if domainOf[e.From] == domainOf[e.To] {
+			continue
+		}
+		line := fmt.Sprintf("- %s → %s (%d imports)", e.From, e.To, e.Imports)
+		if e.Illegal {
+			line += " ❌ " + e.Reason
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
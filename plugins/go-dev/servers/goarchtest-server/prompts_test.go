@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestPlacement(t *testing.T) {
+	tests := []struct {
+		rule namingRule
+		want string
+	}{
+		{rule: namingRule{Layer: "application", Path: "internal/*/application/usecase"}, want: "internal/order/application/usecase"},
+		{rule: namingRule{Layer: "application", Path: "internal/{domain}/application/usecase"}, want: "internal/order/application/usecase"},
+		{rule: namingRule{Layer: "domain"}, want: "the domain layer"},
+	}
+	for _, tt := range tests {
+		if got := placement(tt.rule, "order"); got != tt.want {
+			t.Errorf("placement(%q) = %q, want %q", tt.rule.Path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const rulePurity = "purity"
+
+// stdPackages lists the standard library of the installed toolchain. It is
+// nil when the go command is not available.
+var stdPackages = sync.OnceValue(func() map[string]bool {
+	cmd := exec.Command("go", "list", "std")
+	// Outside any module, so a broken go.mod cannot get in the way.
+	cmd.Dir = os.TempDir()
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	std := make(map[string]bool)
+	for _, line := range strings.Fields(string(out)) {
+		std[line] = true
+	}
+	return std
+})
+
+// isStdLib reports whether importPath belongs to the standard library. Without
+// a toolchain it falls back to the convention that only standard library
+// paths lack a dot in their first element.
+func isStdLib(importPath string) bool {
+	if std := stdPackages(); std != nil {
+		return std[importPath]
+	}
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".") && importPath != "C"
+}
+
+// purityViolations reports imports of pure-layer packages that leave the
+// module: standard library packages on the deny-list and third-party
+// packages that purity.allow does not list. Imports within the module are
+// governed by the layer rules.
+func purityViolations(graph *importGraph, rules *archRules) []violation {
+	pure := rules.pureLayers()
+
+	var violations []violation
+	for _, pkg := range graph.sortedPackages() {
+		_, layer := rules.classify(pkg.relPath)
+		if !slices.Contains(pure, layer) {
+			continue
+		}
+		for _, f := range pkg.files {
+			for _, imp := range f.imports {
+				if _, internal := graph.relImport(imp.path); internal {
+					continue
+				}
+
+				v := violation{
+					Rule:     rulePurity,
+					Severity: severityError,
+					Package:  pkg.importPath,
+					Import:   imp.path,
+					File:     imp.file,
+					Line:     imp.line,
+				}
+				if isStdLib(imp.path) {
+					denied, ok := rules.deniedStd(imp.path)
+					if !ok {
+						continue
+					}
+					v.Message = fmt.Sprintf("%s layer must not import %s", layer, imp.path)
+					if denied != imp.path {
+						v.Message += fmt.Sprintf(" (denied: %s)", denied)
+					}
+					v.Fix = fmt.Sprintf("Keep %s out of the %s layer: declare a port there and implement it in an outer layer", denied, layer)
+				} else {
+					if rules.pureAllowed(imp.path) {
+						continue
+					}
+					v.Message = fmt.Sprintf("%s layer must not import third-party package %s", layer, imp.path)
+					v.Fix = fmt.Sprintf("Declare a port in the %s layer and implement it with %s in an outer layer, or list it under purity.allow", layer, imp.path)
+				}
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations
+}
+
+// domainPurityViolations is everything that keeps the pure layers from being
+// pure: their external imports and their layer dependencies, optionally
+// within a single domain.
+func domainPurityViolations(graph *importGraph, rules *archRules, domain string) []violation {
+	var violations []violation
+	for _, v := range purityViolations(graph, rules) {
+		rel, _ := graph.relImport(v.Package)
+		if d, _ := rules.classify(rel); domain == "" || d == domain {
+			violations = append(violations, v)
+		}
+	}
+	for _, layer := range rules.pureLayers() {
+		violations = append(violations, layerViolations(graph, rules, layer, domain)...)
+		violations = append(violations, protectedViolations(graph, rules, layer, domain)...)
+	}
+	return violations
+}
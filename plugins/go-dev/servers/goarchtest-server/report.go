@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rule identifiers reported with every violation.
+const (
+	ruleLayerDeps       = "layer-deps"
+	ruleDomainIsolation = "domain-isolation"
+	ruleNaming          = "naming"
+)
+
+// ruleInfo describes a rule for report formats that carry rule metadata.
+type ruleInfo struct {
+	ID      string
+	Name    string
+	Summary string
+}
+
+// allRules lists every rule the server evaluates, in report order.
+var allRules = []ruleInfo{
+	{ruleLayerDeps, "LayerDependencies", "A layer imports a layer it must not depend on, or a protected package."},
+	{ruleDomainIsolation, "DomainIsolation", "A bounded context imports another bounded context without an isolation exception."},
+	{ruleNaming, "NamingConvention", "A type declaration breaks the naming rule of its layer: its name suffix, kind, methods or constructor."},
+	{rulePurity, "DomainPurity", "A pure layer imports a denied standard library package or a third-party package."},
+	{ruleExternalDeps, "ExternalDependencies", "A layer imports a third-party module that the external module policy reserves for other layers."},
+	{ruleModuleDeps, "ModuleDependencies", "A module of a go.work workspace imports another workspace module its module rule does not allow."},
+	{rulePorts, "PortsAndAdapters", "A port has no adapter, an adapter implements no port, or a use case depends on a concrete adapter."},
+	{ruleCycle, "ImportCycle", "Packages or bounded contexts import each other in a cycle."},
+}
+
+// checkedRules returns the rules a check evaluates, so that reports can list
+// the rules that passed as well as the ones that failed.
+func checkedRules(check string) []string {
+	switch check {
+	case "check_layer_dependencies":
+		return []string{ruleLayerDeps}
+	case "check_domain_isolation":
+		return []string{ruleDomainIsolation}
+	case "check_naming_conventions":
+		return []string{ruleNaming}
+	case "detect_cycles":
+		return []string{ruleCycle}
+	case "check_external_dependencies":
+		return []string{ruleExternalDeps}
+	case "check_module_dependencies":
+		return []string{ruleModuleDeps}
+	case "check_port_implementations":
+		return []string{rulePorts}
+	case "check_domain_purity":
+		return []string{rulePurity, ruleLayerDeps}
+	case "check_changed_packages":
+		return []string{ruleLayerDeps, ruleDomainIsolation, ruleNaming, rulePurity, ruleExternalDeps, ruleModuleDeps}
+	}
+	ids := make([]string, len(allRules))
+	for i, r := range allRules {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+// Violation severities.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// violation is a single broken architecture rule.
+type violation struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Package  string `json:"package"`
+	Import   string `json:"import,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+	Fix      string `json:"fix,omitempty"`
+}
+
+func (v violation) String() string {
+	if v.File == "" {
+		return fmt.Sprintf("%s: %s", v.Package, v.Message)
+	}
+	if v.Import == "" {
+		return fmt.Sprintf("%s:%d: %s", v.File, v.Line, v.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s imports %s (%s)", v.File, v.Line, v.Package, v.Import, v.Message)
+}
+
+func formatViolations(violations []violation) string {
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = "  - " + v.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkReport is the structured result returned alongside the text of every
+// check, so agents and CI wrappers do not have to parse the prose.
+type checkReport struct {
+	Check       string          `json:"check"`
+	Passed      bool            `json:"passed"`
+	Summary     reportSummary   `json:"summary"`
+	Violations  []violation     `json:"violations"`
+	Ignored     int             `json:"ignored,omitempty"`
+	Baseline    *baselineResult `json:"baseline,omitempty"`
+	ParseErrors []string        `json:"parseErrors,omitempty"`
+}
+
+type reportSummary struct {
+	Total    int            `json:"total"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	ByRule   map[string]int `json:"byRule"`
+}
+
+// newCheckReport sorts violations by location and computes the summary. A
+// check passes when it found no error-severity violations.
+func newCheckReport(check string, violations []violation) checkReport {
+	if violations == nil {
+		violations = []violation{}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].File != violations[j].File {
+			return violations[i].File < violations[j].File
+		}
+		return violations[i].Line < violations[j].Line
+	})
+
+	summary := reportSummary{Total: len(violations), ByRule: make(map[string]int)}
+	for _, v := range violations {
+		switch v.Severity {
+		case severityError:
+			summary.Errors++
+		case severityWarning:
+			summary.Warnings++
+		}
+		summary.ByRule[v.Rule]++
+	}
+
+	return checkReport{
+		Check:      check,
+		Passed:     summary.Errors == 0,
+		Summary:    summary,
+		Violations: violations,
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// Resource URIs. Clients can attach them as context without calling a tool;
+// every resource is computed from the cached graph when it is read.
+const (
+	rulesURI         = "arch://rules"
+	domainsURI       = "arch://domains"
+	graphURI         = "arch://graph"
+	graphTemplateURI = "arch://graph/{domain}"
+	violationsURI    = "arch://violations"
+)
+
+func (s *GoArchTestServer) setupResources() {
+	s.mcpServer.AddResource(
+		mcp.NewResource(rulesURI, "Architecture rules",
+			mcp.WithResourceDescription("Active layer, naming, isolation, protected-package, purity, external and workspace module rules, from .goarch.yaml or the built-in defaults; one YAML document per module in a go.work workspace"),
+			mcp.WithMIMEType("application/yaml"),
+		),
+		s.readRules,
+	)
+
+	s.mcpServer.AddResource(
+		mcp.NewResource(domainsURI, "Bounded contexts",
+			mcp.WithResourceDescription("Every bounded context with its layers and packages, and packages that fit no layer"),
+			mcp.WithMIMEType("application/json"),
+		),
+		s.readDomains,
+	)
+
+	s.mcpServer.AddResource(
+		mcp.NewResource(graphURI, "Dependency graph",
+			mcp.WithResourceDescription("Domain/layer dependency graph of the whole project with illegal edges flagged"),
+			mcp.WithMIMEType("application/json"),
+		),
+		s.readGraph,
+	)
+
+	s.mcpServer.AddResourceTemplate(
+		mcp.NewResourceTemplate(graphTemplateURI, "Domain dependency graph",
+			mcp.WithTemplateDescription("Layer dependency graph of one bounded context and the edges that leave or enter it"),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		s.readGraph,
+	)
+
+	s.mcpServer.AddResource(
+		mcp.NewResource(violationsURI, "Architecture violations",
+			mcp.WithResourceDescription("Every current rule violation and import cycle, minus the entries of "+baselineFileName),
+			mcp.WithMIMEType("application/json"),
+		),
+		s.readViolations,
+	)
+}
+
+func (s *GoArchTestServer) readRules(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	servers, err := s.moduleServers("")
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []string
+	for _, m := range servers {
+		_, rules, err := m.analyze()
+		if err != nil {
+			return nil, err
+		}
+		data, err := yaml.Marshal(rules)
+		if err != nil {
+			return nil, fmt.Errorf("encoding rules: %w", err)
+		}
+		header := fmt.Sprintf("# source: %s\n", rules.describe())
+		if s.workspace != nil {
+			header = fmt.Sprintf("# module: %s (%s)\n", m.module.Path, m.module.Dir) + header
+		}
+		docs = append(docs, header+string(data))
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      request.Params.URI,
+		MIMEType: "application/yaml",
+		Text:     strings.Join(docs, "---\n"),
+	}}, nil
+}
+
+func (s *GoArchTestServer) readDomains(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return s.moduleResource(request.Params.URI, func(m *GoArchTestServer) (any, error) {
+		graph, rules, err := m.analyze()
+		if err != nil {
+			return nil, err
+		}
+		return discoverArchitecture(graph, rules), nil
+	})
+}
+
+// readGraph serves both the project graph and the per-domain template; the
+// domain is the path segment after arch://graph/. In a workspace the
+// template covers the modules that have the domain.
+func (s *GoArchTestServer) readGraph(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	domain := strings.TrimPrefix(strings.TrimPrefix(request.Params.URI, graphURI), "/")
+	return s.moduleResource(request.Params.URI, func(m *GoArchTestServer) (any, error) {
+		graph, rules, err := m.analyze()
+		if err != nil {
+			return nil, err
+		}
+		if domain != "" && !slices.Contains(discoveredDomains(graph, rules), domain) {
+			if s.workspace != nil {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown domain %q, expected one of: %s", domain, strings.Join(discoveredDomains(graph, rules), ", "))
+		}
+		return buildLayerGraph(graph, rules, domain), nil
+	})
+}
+
+func (s *GoArchTestServer) readViolations(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return s.moduleResource(request.Params.URI, func(m *GoArchTestServer) (any, error) {
+		graph, rules, err := m.analyze()
+		if err != nil {
+			return nil, err
+		}
+		_, violations := runAllRules(graph, rules)
+		report := newCheckReport("violations", violations)
+		ignoreSuppressed(graph, &report)
+		if err := m.subtractBaseline(graph, rules, &report); err != nil {
+			return nil, err
+		}
+		report.ParseErrors = graph.parseErrors()
+		return report, nil
+	})
+}
+
+// moduleResource encodes the value read returns for the server outside a
+// workspace. In a workspace it encodes the values of every module that
+// returns one, grouped by module, and fails when none does.
+func (s *GoArchTestServer) moduleResource(uri string, read func(m *GoArchTestServer) (any, error)) ([]mcp.ResourceContents, error) {
+	if s.workspace == nil {
+		v, err := read(s)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResource(uri, v)
+	}
+
+	type moduleData struct {
+		Module string `json:"module"`
+		Dir    string `json:"dir"`
+		Data   any    `json:"data"`
+	}
+	grouped := struct {
+		Modules []moduleData `json:"modules"`
+	}{[]moduleData{}}
+	for _, m := range s.workspace.modules {
+		v, err := read(m.server)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Dir, err)
+		}
+		if v != nil {
+			grouped.Modules = append(grouped.Modules, moduleData{m.Path, m.Dir, v})
+		}
+	}
+	if len(grouped.Modules) == 0 {
+		return nil, fmt.Errorf("no workspace module provides %s", uri)
+	}
+	return jsonResource(uri, grouped)
+}
+
+func jsonResource(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", uri, err)
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}}, nil
+}
@@ -0,0 +1,632 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rulesFileNames are the project-level rules files, in lookup order.
+var rulesFileNames = []string{".goarch.yaml", ".goarch.yml"}
+
+// domainPlaceholder is the path segment that captures the bounded context
+// name in layer and domain patterns.
+const domainPlaceholder = "{domain}"
+
+// archRules is the declarative architecture of a project. It is read from
+// .goarch.yaml when present and defaults to the internal/<domain>/<layer>
+// hexagonal layout otherwise.
+type archRules struct {
+	Version      int            `yaml:"version"`
+	DomainPath   string         `yaml:"domainPath"`
+	SharedKernel []string       `yaml:"sharedKernel"`
+	Layers       []layerRule    `yaml:"layers"`
+	Naming       []namingRule   `yaml:"naming,omitempty"`
+	Isolation    isolationRules `yaml:"isolation,omitempty"`
+	Protected    []string       `yaml:"protected,omitempty"`
+	Purity       purityRules    `yaml:"purity"`
+	External     []externalRule `yaml:"external,omitempty"`
+	Modules      []moduleRule   `yaml:"modules,omitempty"`
+
+	// source is the file the rules were read from, empty for the defaults.
+	source string
+	// workspace lists the module paths of the go.work workspace the rules
+	// apply in, empty outside a workspace.
+	workspace []string
+}
+
+// layerRule declares a layer, the package paths that belong to it and the
+// other layers it may import. Unless CrossDomain is set, a layer must not
+// import the same layer of another domain.
+type layerRule struct {
+	Name        string   `yaml:"name"`
+	Paths       []string `yaml:"paths"`
+	DependsOn   []string `yaml:"dependsOn,omitempty"`
+	CrossDomain bool     `yaml:"crossDomain,omitempty"`
+}
+
+// namingRule governs the type declarations of a layer, optionally narrowed
+// by path. Exported types ending with Suffix must be of Kind, when set, and
+// declare Methods; types of Kind that declare all Methods must end with
+// Suffix. With Constructor set, each of them needs a New<Type> function.
+// With Required set, the packages Path names, but not their sub-packages,
+// must declare a type ending with Suffix.
+type namingRule struct {
+	Name        string   `yaml:"name"`
+	Layer       string   `yaml:"layer"`
+	Path        string   `yaml:"path,omitempty"`
+	Suffix      string   `yaml:"suffix"`
+	Kind        string   `yaml:"kind,omitempty"`
+	Methods     []string `yaml:"methods,omitempty"`
+	Constructor bool     `yaml:"constructor,omitempty"`
+	Required    bool     `yaml:"required,omitempty"`
+}
+
+type isolationRules struct {
+	Exceptions []isolationException `yaml:"exceptions,omitempty"`
+}
+
+// isolationException allows imports between two bounded contexts. From and
+// To accept path.Match wildcards, so {from: "*", to: shared} opens a shared
+// kernel to every domain.
+type isolationException struct {
+	From   string `yaml:"from"`
+	To     string `yaml:"to"`
+	Reason string `yaml:"reason,omitempty"`
+}
+
+// purityRules restricts what the pure layers may import from outside the
+// module. Layers defaults to every layer that depends on no other layer.
+// Deny lists standard library packages, with their sub-packages, that pure
+// code must not use; Allow lists third-party package patterns it may use.
+type purityRules struct {
+	Layers []string `yaml:"layers,omitempty"`
+	Deny   []string `yaml:"deny"`
+	Allow  []string `yaml:"allow,omitempty"`
+}
+
+// externalRule restricts the modules matching the Module pattern, such as
+// "gorm.io/**", to the packages of Layers and the package patterns of
+// Paths. Patterns cover every package of a module.
+type externalRule struct {
+	Module string   `yaml:"module"`
+	Layers []string `yaml:"layers,omitempty"`
+	Paths  []string `yaml:"paths,omitempty"`
+	Reason string   `yaml:"reason,omitempty"`
+}
+
+// moduleRule restricts the workspace modules matching the Module pattern to
+// importing the other modules of the go.work workspace that match Allow.
+// Modules outside the workspace are governed by the external rules instead.
+type moduleRule struct {
+	Module string   `yaml:"module"`
+	Allow  []string `yaml:"allow"`
+	Reason string   `yaml:"reason,omitempty"`
+}
+
+// defaultSharedKernel is the shared-kernel directory of the layout the
+// plugin scaffolds, internal/shared.
+var defaultSharedKernel = []string{"shared"}
+
+// defaultPurityDeny keeps I/O, system calls and process state out of the
+// pure layers.
+var defaultPurityDeny = []string{"os", "net", "syscall", "database/sql", "io/ioutil", "log", "plugin", "unsafe"}
+
+func defaultRules() *archRules {
+	return &archRules{
+		Version:      1,
+		DomainPath:   "internal/{domain}",
+		SharedKernel: defaultSharedKernel,
+		Layers: []layerRule{
+			{Name: "domain", Paths: []string{"internal/{domain}/domain"}},
+			{Name: "application", Paths: []string{"internal/{domain}/application"}, DependsOn: []string{"domain"}},
+			{Name: "infrastructure", Paths: []string{"internal/{domain}/infrastructure"}, DependsOn: []string{"domain", "application"}},
+		},
+		Naming: []namingRule{
+			{Name: "repository", Layer: "domain", Suffix: "Repository", Kind: kindInterface},
+			{Name: "usecase", Layer: "application", Path: "internal/*/application/usecase", Suffix: "UseCase", Kind: kindStruct, Methods: []string{"Execute"}, Constructor: true},
+			{Name: "handler", Layer: "infrastructure", Path: "internal/*/infrastructure/http", Suffix: "Handler", Kind: kindStruct, Constructor: true},
+		},
+		Protected: []string{"cmd"},
+		Purity:    purityRules{Deny: defaultPurityDeny},
+	}
+}
+
+// findRulesFile returns the rules file in root, or "" when there is none.
+func findRulesFile(root string) string {
+	for _, name := range rulesFileNames {
+		p := filepath.Join(root, name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// loadRules reads the project's rules file. Without one it returns the
+// defaults; with an invalid one it returns every problem found.
+func loadRules(root string) (*archRules, []string, error) {
+	p := findRulesFile(root)
+	if p == "" {
+		return defaultRules(), nil, nil
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading rules file: %w", err)
+	}
+
+	rules, problems := parseRules(data)
+	if len(problems) > 0 {
+		return nil, problems, nil
+	}
+	rules.source = p
+	return rules, nil, nil
+}
+
+// parseRules decodes and validates a rules file.
+func parseRules(data []byte) (*archRules, []string) {
+	var rules archRules
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&rules); err != nil && !errors.Is(err, io.EOF) {
+		return nil, []string{err.Error()}
+	}
+
+	if rules.DomainPath == "" {
+		rules.DomainPath = defaultRules().DomainPath
+	}
+	if rules.SharedKernel == nil {
+		rules.SharedKernel = defaultSharedKernel
+	}
+	if rules.Protected == nil {
+		rules.Protected = defaultRules().Protected
+	}
+	if rules.Purity.Deny == nil {
+		rules.Purity.Deny = defaultPurityDeny
+	}
+
+	if problems := rules.validate(); len(problems) > 0 {
+		return nil, problems
+	}
+	return &rules, nil
+}
+
+func (r *archRules) validate() []string {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if r.Version != 1 {
+		addf("version: must be 1, got %d", r.Version)
+	}
+	if err := checkPattern(r.DomainPath); err != nil {
+		addf("domainPath: %v", err)
+	} else if !strings.Contains(r.DomainPath, domainPlaceholder) {
+		addf("domainPath: must contain %s", domainPlaceholder)
+	}
+
+	for i, s := range r.SharedKernel {
+		if s == "" || strings.Contains(s, "/") {
+			addf("sharedKernel[%d]: must be a domain name, got %q", i, s)
+		} else if _, err := path.Match(s, ""); err != nil {
+			addf("sharedKernel[%d]: %v", i, err)
+		}
+	}
+
+	if len(r.Layers) == 0 {
+		addf("layers: at least one layer is required")
+	}
+	layers := make(map[string]bool)
+	for i, l := range r.Layers {
+		switch {
+		case l.Name == "":
+			addf("layers[%d].name: is required", i)
+		case layers[l.Name]:
+			addf("layers[%d].name: duplicate layer %q", i, l.Name)
+		}
+		layers[l.Name] = true
+
+		if len(l.Paths) == 0 {
+			addf("layers[%d].paths: at least one path is required", i)
+		}
+		for j, p := range l.Paths {
+			if err := checkPattern(p); err != nil {
+				addf("layers[%d].paths[%d]: %v", i, j, err)
+			}
+		}
+	}
+	for i, l := range r.Layers {
+		for j, dep := range l.DependsOn {
+			if !layers[dep] {
+				addf("layers[%d].dependsOn[%d]: unknown layer %q", i, j, dep)
+			}
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, n := range r.Naming {
+		switch {
+		case n.Name == "":
+			addf("naming[%d].name: is required", i)
+		case names[n.Name]:
+			addf("naming[%d].name: duplicate rule %q", i, n.Name)
+		}
+		names[n.Name] = true
+
+		if !layers[n.Layer] {
+			addf("naming[%d].layer: unknown layer %q", i, n.Layer)
+		}
+		if n.Suffix == "" {
+			addf("naming[%d].suffix: is required", i)
+		}
+		if n.Path != "" {
+			if err := checkPattern(n.Path); err != nil {
+				addf("naming[%d].path: %v", i, err)
+			}
+		} else if n.Required {
+			addf("naming[%d].required: needs a path naming the packages that must declare the type", i)
+		}
+		switch n.Kind {
+		case "", kindStruct:
+		case kindInterface:
+			if n.Constructor {
+				addf("naming[%d].constructor: interfaces have no constructors", i)
+			}
+		default:
+			addf("naming[%d].kind: must be %s or %s, got %q", i, kindInterface, kindStruct, n.Kind)
+		}
+		for j, m := range n.Methods {
+			if !token.IsIdentifier(m) || !token.IsExported(m) {
+				addf("naming[%d].methods[%d]: %q is not an exported method name", i, j, m)
+			}
+		}
+	}
+
+	for i, e := range r.Isolation.Exceptions {
+		if e.From == "" || e.To == "" {
+			addf("isolation.exceptions[%d]: from and to are required", i)
+			continue
+		}
+		if _, err := path.Match(e.From, ""); err != nil {
+			addf("isolation.exceptions[%d].from: %v", i, err)
+		}
+		if _, err := path.Match(e.To, ""); err != nil {
+			addf("isolation.exceptions[%d].to: %v", i, err)
+		}
+	}
+
+	for i, p := range r.Protected {
+		if err := checkPattern(p); err != nil {
+			addf("protected[%d]: %v", i, err)
+		}
+	}
+
+	for i, l := range r.Purity.Layers {
+		if !layers[l] {
+			addf("purity.layers[%d]: unknown layer %q", i, l)
+		}
+	}
+	for i, d := range r.Purity.Deny {
+		if strings.Trim(d, "/") == "" {
+			addf("purity.deny[%d]: package is empty", i)
+		}
+	}
+	for i, p := range r.Purity.Allow {
+		if err := checkPattern(p); err != nil {
+			addf("purity.allow[%d]: %v", i, err)
+		}
+	}
+
+	for i, e := range r.External {
+		if e.Module == "" {
+			addf("external[%d].module: is required", i)
+		} else if err := checkPattern(e.Module); err != nil {
+			addf("external[%d].module: %v", i, err)
+		}
+		if len(e.Layers) == 0 && len(e.Paths) == 0 {
+			addf("external[%d]: at least one of layers and paths is required", i)
+		}
+		for j, l := range e.Layers {
+			if !layers[l] {
+				addf("external[%d].layers[%d]: unknown layer %q", i, j, l)
+			}
+		}
+		for j, p := range e.Paths {
+			if err := checkPattern(p); err != nil {
+				addf("external[%d].paths[%d]: %v", i, j, err)
+			}
+		}
+	}
+
+	for i, m := range r.Modules {
+		if m.Module == "" {
+			addf("modules[%d].module: is required", i)
+		} else if err := checkPattern(m.Module); err != nil {
+			addf("modules[%d].module: %v", i, err)
+		}
+		for j, a := range m.Allow {
+			if err := checkPattern(a); err != nil {
+				addf("modules[%d].allow[%d]: %v", i, j, err)
+			}
+		}
+	}
+
+	return problems
+}
+
+// checkPattern validates a package path pattern: slash-separated segments
+// that are path.Match globs, "**" or the domain placeholder, which may appear
+// at most once.
+func checkPattern(pattern string) error {
+	if strings.Trim(pattern, "/") == "" {
+		return errors.New("pattern is empty")
+	}
+	placeholders := 0
+	for _, seg := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		switch seg {
+		case domainPlaceholder:
+			placeholders++
+		case "**":
+		default:
+			if strings.Contains(seg, domainPlaceholder) {
+				return fmt.Errorf("%s must be a whole path segment in %q", domainPlaceholder, pattern)
+			}
+			if _, err := path.Match(seg, ""); err != nil {
+				return fmt.Errorf("invalid segment %q in %q: %w", seg, pattern, err)
+			}
+		}
+	}
+	if placeholders > 1 {
+		return fmt.Errorf("%s may appear only once in %q", domainPlaceholder, pattern)
+	}
+	return nil
+}
+
+// matchPath reports whether the module-relative package path rel lies in
+// pattern (the package itself or any sub-package) and returns the domain
+// captured by the placeholder, if any.
+func matchPath(pattern, rel string) (string, bool) {
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(rel, "/"), "", false)
+}
+
+// matchPackage reports whether rel is a package pattern names itself, not
+// one of its sub-packages.
+func matchPackage(pattern, rel string) bool {
+	_, ok := matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(rel, "/"), "", true)
+	return ok
+}
+
+func matchSegments(pattern, segs []string, domain string, exact bool) (string, bool) {
+	if len(pattern) == 0 {
+		return domain, !exact || len(segs) == 0
+	}
+	switch p := pattern[0]; {
+	case p == "**":
+		for i := 0; i <= len(segs); i++ {
+			if d, ok := matchSegments(pattern[1:], segs[i:], domain, exact); ok {
+				return d, true
+			}
+		}
+		return "", false
+	case len(segs) == 0:
+		return "", false
+	case p == domainPlaceholder:
+		return matchSegments(pattern[1:], segs[1:], segs[0], exact)
+	default:
+		if ok, err := path.Match(p, segs[0]); err != nil || !ok {
+			return "", false
+		}
+		return matchSegments(pattern[1:], segs[1:], domain, exact)
+	}
+}
+
+// classify places a module-relative package path in a domain and layer.
+// Packages that match no layer keep their domain, if any, and an empty layer.
+// The shared kernel is no bounded context, so its packages have no domain.
+func (r *archRules) classify(rel string) (domain, layer string) {
+	domain, _ = matchPath(r.DomainPath, rel)
+	for _, l := range r.Layers {
+		for _, p := range l.Paths {
+			if d, ok := matchPath(p, rel); ok {
+				if d == "" {
+					d = domain
+				}
+				return r.boundedContext(d), l.Name
+			}
+		}
+	}
+	return r.boundedContext(domain), ""
+}
+
+// boundedContext returns domain, or "" when it names the shared kernel.
+func (r *archRules) boundedContext(domain string) string {
+	for _, s := range r.SharedKernel {
+		if ok, _ := path.Match(s, domain); ok {
+			return ""
+		}
+	}
+	return domain
+}
+
+func (r *archRules) layer(name string) (layerRule, bool) {
+	for _, l := range r.Layers {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return layerRule{}, false
+}
+
+func (r *archRules) layerNames() []string {
+	names := make([]string, len(r.Layers))
+	for i, l := range r.Layers {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// innerLayers returns the layers that depend on no other layer, which hold
+// the domain model and its ports.
+func (r *archRules) innerLayers() []string {
+	var names []string
+	for _, l := range r.Layers {
+		if len(l.DependsOn) == 0 {
+			names = append(names, l.Name)
+		}
+	}
+	return names
+}
+
+// outerLayers returns the layers no other layer may depend on, such as
+// infrastructure, unless they are inner layers too.
+func (r *archRules) outerLayers() []string {
+	depended := make(map[string]bool)
+	for _, l := range r.Layers {
+		for _, dep := range l.DependsOn {
+			depended[dep] = true
+		}
+	}
+	var names []string
+	for _, l := range r.Layers {
+		if !depended[l.Name] && len(l.DependsOn) > 0 {
+			names = append(names, l.Name)
+		}
+	}
+	return names
+}
+
+// pureLayers returns the layers whose imports the purity rules restrict.
+func (r *archRules) pureLayers() []string {
+	if len(r.Purity.Layers) > 0 {
+		return r.Purity.Layers
+	}
+	return r.innerLayers()
+}
+
+// deniedStd returns the deny-list entry that forbids the standard library
+// package importPath in pure layers, if any.
+func (r *archRules) deniedStd(importPath string) (string, bool) {
+	for _, d := range r.Purity.Deny {
+		if inTree(strings.Trim(d, "/"), importPath) {
+			return d, true
+		}
+	}
+	return "", false
+}
+
+// pureAllowed reports whether pure layers may import the third-party package
+// importPath.
+func (r *archRules) pureAllowed(importPath string) bool {
+	for _, p := range r.Purity.Allow {
+		if _, ok := matchPath(p, importPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// externalPolicy returns the first external rule covering the package
+// importPath from outside the module.
+func (r *archRules) externalPolicy(importPath string) (externalRule, bool) {
+	for _, e := range r.External {
+		if _, ok := matchPath(e.Module, importPath); ok {
+			return e, true
+		}
+	}
+	return externalRule{}, false
+}
+
+// moduleRule returns the first module rule covering the workspace module
+// modulePath.
+func (r *archRules) moduleRule(modulePath string) (moduleRule, bool) {
+	for _, m := range r.Modules {
+		if _, ok := matchPath(m.Module, modulePath); ok {
+			return m, true
+		}
+	}
+	return moduleRule{}, false
+}
+
+func (r *archRules) namingRule(name string) (namingRule, bool) {
+	for _, n := range r.Naming {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return namingRule{}, false
+}
+
+func (r *archRules) namingRuleNames() []string {
+	names := make([]string, len(r.Naming))
+	for i, n := range r.Naming {
+		names[i] = n.Name
+	}
+	return names
+}
+
+// layerAllowed reports whether a package in layer from may import a package
+// in layer to. Packages outside any layer are unconstrained.
+func (r *archRules) layerAllowed(from, to string) bool {
+	if from == "" || to == "" || from == to {
+		return true
+	}
+	l, ok := r.layer(from)
+	if !ok {
+		return true
+	}
+	for _, dep := range l.DependsOn {
+		if dep == to {
+			return true
+		}
+	}
+	return false
+}
+
+// protectedTarget returns the protected pattern that forbids a package at
+// from importing the package at to, if any. Packages inside a protected tree
+// may still import each other.
+func (r *archRules) protectedTarget(from, to string) (string, bool) {
+	for _, p := range r.Protected {
+		if _, ok := matchPath(p, to); !ok {
+			continue
+		}
+		if _, ok := matchPath(p, from); ok {
+			continue
+		}
+		return p, true
+	}
+	return "", false
+}
+
+// domainsIsolated reports whether domain from must not import domain to.
+func (r *archRules) domainsIsolated(from, to string) bool {
+	if from == "" || to == "" || from == to {
+		return false
+	}
+	for _, e := range r.Isolation.Exceptions {
+		fromOK, _ := path.Match(e.From, from)
+		toOK, _ := path.Match(e.To, to)
+		if fromOK && toOK {
+			return false
+		}
+	}
+	return true
+}
+
+// describe renders the rules source for tool output.
+func (r *archRules) describe() string {
+	if r.source == "" {
+		return "built-in defaults"
+	}
+	return r.source
+}
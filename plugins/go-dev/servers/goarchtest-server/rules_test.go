@@ -0,0 +1,166 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, rel string
+		domain       string
+		ok           bool
+	}{
+		{pattern: "internal/{domain}/domain", rel: "internal/order/domain", domain: "order", ok: true},
+		{pattern: "internal/{domain}/domain", rel: "internal/order/domain/model", domain: "order", ok: true},
+		{pattern: "internal/{domain}/domain", rel: "internal/order/domainx"},
+		{pattern: "internal/{domain}/domain", rel: "internal/order"},
+		{pattern: "internal/{domain}", rel: "internal/order/application", domain: "order", ok: true},
+		{pattern: "/internal/{domain}/", rel: "internal/order", domain: "order", ok: true},
+		{pattern: "services/*/{domain}/core", rel: "services/eu/billing/core", domain: "billing", ok: true},
+		{pattern: "**/domain", rel: "internal/order/domain", ok: true},
+		{pattern: "**/domain", rel: "domain", ok: true},
+		{pattern: "internal/**/{domain}/domain", rel: "internal/a/b/order/domain", domain: "order", ok: true},
+		{pattern: "pkg", rel: "pkg/util", ok: true},
+		{pattern: "pkg", rel: "pkgs"},
+	}
+	for _, tt := range tests {
+		domain, ok := matchPath(tt.pattern, tt.rel)
+		if domain != tt.domain || ok != tt.ok {
+			t.Errorf("matchPath(%q, %q) = %q, %v, want %q, %v", tt.pattern, tt.rel, domain, ok, tt.domain, tt.ok)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	custom := defaultRules()
+	custom.DomainPath = "services/{domain}"
+	custom.SharedKernel = []string{"common", "shared*"}
+	custom.Layers = []layerRule{
+		{Name: "core", Paths: []string{"services/{domain}/core", "lib/corelib"}},
+		{Name: "adapters", Paths: []string{"services/{domain}/adapters"}, DependsOn: []string{"core"}},
+	}
+
+	tests := []struct {
+		rules         *archRules
+		rel           string
+		domain, layer string
+	}{
+		{rel: "internal/order/domain", domain: "order", layer: "domain"},
+		{rel: "internal/order/domain/model", domain: "order", layer: "domain"},
+		{rel: "internal/order/application/usecase", domain: "order", layer: "application"},
+		{rel: "internal/order/infrastructure", domain: "order", layer: "infrastructure"},
+		{rel: "internal/order/events", domain: "order"},
+		{rel: "internal/shared/domain", layer: "domain"},
+		{rel: "internal/shared/money"},
+		{rel: "cmd/app"},
+		{rel: "."},
+		{rules: custom, rel: "services/billing/core", domain: "billing", layer: "core"},
+		{rules: custom, rel: "services/billing/adapters/sql", domain: "billing", layer: "adapters"},
+		{rules: custom, rel: "services/common/core", layer: "core"},
+		{rules: custom, rel: "services/shared-kernel/core", layer: "core"},
+		{rules: custom, rel: "lib/corelib", layer: "core"},
+		{rules: custom, rel: "internal/order/domain"},
+	}
+	for _, tt := range tests {
+		rules := tt.rules
+		if rules == nil {
+			rules = defaultRules()
+		}
+		domain, layer := rules.classify(tt.rel)
+		if domain != tt.domain || layer != tt.layer {
+			t.Errorf("classify(%q) = %q, %q, want %q, %q", tt.rel, domain, layer, tt.domain, tt.layer)
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		problems []string
+	}{
+		{
+			name: "minimal file uses the defaults",
+			yaml: "version: 1\nlayers:\n  - name: domain\n    paths: [\"internal/{domain}/domain\"]\n",
+		},
+		{
+			name:     "unknown field",
+			yaml:     "version: 1\nlayer: []\n",
+			problems: []string{"field layer not found"},
+		},
+		{
+			name: "invalid layers",
+			yaml: `version: 2
+domainPath: internal/app
+layers:
+  - name: domain
+    paths: ["internal/{domain}/{domain}"]
+  - name: domain
+    paths: []
+    dependsOn: [persistence]
+`,
+			problems: []string{
+				"version: must be 1, got 2",
+				"domainPath: must contain {domain}",
+				"layers[0].paths[0]: {domain} may appear only once",
+				`layers[1].name: duplicate layer "domain"`,
+				"layers[1].paths: at least one path is required",
+				`layers[1].dependsOn[0]: unknown layer "persistence"`,
+			},
+		},
+		{
+			name: "invalid naming and isolation",
+			yaml: `version: 1
+layers:
+  - name: domain
+    paths: ["internal/{domain}/domain"]
+naming:
+  - name: repository
+    layer: persistence
+isolation:
+  exceptions:
+    - from: order
+    - from: "["
+      to: billing
+`,
+			problems: []string{
+				`naming[0].layer: unknown layer "persistence"`,
+				"naming[0].suffix: is required",
+				"isolation.exceptions[0]: from and to are required",
+				"isolation.exceptions[1].from: syntax error in pattern",
+			},
+		},
+		{
+			name:     "placeholder inside a segment",
+			yaml:     "version: 1\nlayers:\n  - name: domain\n    paths: [\"internal/x{domain}/domain\"]\n",
+			problems: []string{"layers[0].paths[0]: {domain} must be a whole path segment"},
+		},
+		{
+			name:     "shared kernel is a domain name",
+			yaml:     "version: 1\nsharedKernel: [internal/shared]\nlayers:\n  - name: domain\n    paths: [\"internal/{domain}/domain\"]\n",
+			problems: []string{`sharedKernel[0]: must be a domain name, got "internal/shared"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, problems := parseRules([]byte(tt.yaml))
+			if len(problems) != len(tt.problems) {
+				t.Fatalf("problems = %q, want %q", problems, tt.problems)
+			}
+			for i, want := range tt.problems {
+				if !strings.Contains(problems[i], want) {
+					t.Errorf("problem %d = %q, want it to contain %q", i, problems[i], want)
+				}
+			}
+			if len(tt.problems) > 0 {
+				return
+			}
+			if rules.DomainPath != "internal/{domain}" || !slices.Equal(rules.SharedKernel, defaultSharedKernel) {
+				t.Errorf("defaults not applied: domainPath %q, sharedKernel %q", rules.DomainPath, rules.SharedKernel)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIF 2.1.0 subset written by sarifReport. Only the properties code
+// scanning dashboards read are modeled.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	DefaultConfig    sarifConfig  `json:"defaultConfiguration"`
+}
+
+type sarifConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifReport renders report as a SARIF 2.1.0 log with one result per
+// violation, located at the offending import or declaration. Violations that
+// belong to a whole package point at line 1 of one of its files, which
+// packageFile maps the import path to; without one they keep only the
+// logical location of the package.
+func sarifReport(report checkReport, packageFile func(importPath string) (string, bool)) ([]byte, error) {
+	driver := sarifDriver{
+		Name:           serverName,
+		Version:        serverVersion,
+		InformationURI: "https://github.com/solrac97gr/marketplace-plugins/tree/main/plugins/go-dev/servers/goarchtest-server",
+		Rules:          []sarifRule{},
+	}
+	ruleIndex := make(map[string]int)
+	for _, r := range allRules {
+		ruleIndex[r.ID] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               r.ID,
+			Name:             r.Name,
+			ShortDescription: sarifMessage{Text: r.Summary},
+			DefaultConfig:    sarifConfig{Level: "error"},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, v := range report.Violations {
+		message := v.Message
+		if v.Import != "" {
+			message = v.Package + " imports " + v.Import + ": " + message
+		}
+		if v.Fix != "" {
+			message += ". " + v.Fix
+		}
+
+		loc := sarifLocation{
+			LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: v.Package, Kind: "package"}},
+		}
+		if v.File != "" {
+			loc.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: v.File, URIBaseID: "%SRCROOT%"},
+				Region:           &sarifRegion{StartLine: max(v.Line, 1)},
+			}
+		} else if file, ok := packageFile(v.Package); ok {
+			loc.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: file, URIBaseID: "%SRCROOT%"},
+				Region:           &sarifRegion{StartLine: 1},
+			}
+		}
+
+		sum := sha256.Sum256([]byte(v.fingerprint()))
+		results = append(results, sarifResult{
+			RuleID:              v.Rule,
+			RuleIndex:           ruleIndex[v.Rule],
+			Level:               sarifLevel(v.Severity),
+			Message:             sarifMessage{Text: message},
+			Locations:           []sarifLocation{loc},
+			PartialFingerprints: map[string]string{"goarchtest/v1": hex.EncodeToString(sum[:])},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func sarifLevel(severity string) string {
+	if severity == severityWarning {
+		return "warning"
+	}
+	return "error"
+}
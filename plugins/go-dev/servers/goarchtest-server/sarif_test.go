@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSarifReport(t *testing.T) {
+	report := newCheckReport("check_layer_dependencies", []violation{
+		{
+			Rule: ruleLayerDeps, Severity: severityError,
+			Package: "ex/internal/order/domain", Import: "ex/internal/order/infrastructure",
+			File: "internal/order/domain/order.go", Line: 5,
+			Message: "domain layer must not import infrastructure layer", Fix: "Depend on a port",
+		},
+		{
+			Rule: ruleNaming, Severity: severityError,
+			Package: "ex/internal/order/domain", File: "internal/order/domain/order.go",
+			Message: "no line",
+		},
+		{
+			Rule: rulePorts, Severity: severityWarning,
+			Package: "ex/internal/order/application",
+			Message: "port Repository has no adapter",
+		},
+		{
+			Rule: ruleCycle, Severity: severityError,
+			Package: "ex/elsewhere",
+			Message: "outside the module",
+		},
+	})
+	packageFile := func(importPath string) (string, bool) {
+		file, ok := map[string]string{"ex/internal/order/application": "internal/order/application/service.go"}[importPath]
+		return file, ok
+	}
+
+	data, err := sarifReport(report, packageFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if log.Version != "2.1.0" || log.Schema != sarifSchema || len(log.Runs) != 1 {
+		t.Fatalf("log = %s %s with %d runs", log.Schema, log.Version, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(allRules) {
+		t.Errorf("driver declares %d rules, want %d", len(run.Tool.Driver.Rules), len(allRules))
+	}
+	if len(run.Results) != len(report.Violations) {
+		t.Fatalf("got %d results, want %d", len(run.Results), len(report.Violations))
+	}
+	byRule := make(map[string]sarifResult)
+	for _, r := range run.Results {
+		if got := run.Tool.Driver.Rules[r.RuleIndex].ID; got != r.RuleID {
+			t.Errorf("result %s points at rule %s", r.RuleID, got)
+		}
+		if r.PartialFingerprints["goarchtest/v1"] == "" {
+			t.Errorf("result %s has no fingerprint", r.RuleID)
+		}
+		byRule[r.RuleID] = r
+	}
+
+	imp := byRule[ruleLayerDeps]
+	wantMessage := "ex/internal/order/domain imports ex/internal/order/infrastructure: domain layer must not import infrastructure layer. Depend on a port"
+	if imp.Message.Text != wantMessage {
+		t.Errorf("message = %q, want %q", imp.Message.Text, wantMessage)
+	}
+	if loc := imp.Locations[0].PhysicalLocation; loc == nil || loc.ArtifactLocation.URI != "internal/order/domain/order.go" || loc.Region.StartLine != 5 {
+		t.Errorf("import location = %+v", loc)
+	}
+	if loc := byRule[ruleNaming].Locations[0].PhysicalLocation; loc == nil || loc.Region.StartLine != 1 {
+		t.Errorf("a violation without a line must start at line 1, got %+v", loc)
+	}
+
+	port := byRule[rulePorts]
+	if port.Level != "warning" || imp.Level != "error" {
+		t.Errorf("levels = %s, %s, want warning, error", port.Level, imp.Level)
+	}
+	if loc := port.Locations[0].PhysicalLocation; loc == nil || loc.ArtifactLocation.URI != "internal/order/application/service.go" || loc.Region == nil || loc.Region.StartLine != 1 {
+		t.Errorf("package location = %+v", loc)
+	}
+
+	cycle := byRule[ruleCycle]
+	if cycle.Locations[0].PhysicalLocation != nil {
+		t.Errorf("unknown package got a physical location: %+v", cycle.Locations[0].PhysicalLocation)
+	}
+	if ll := cycle.Locations[0].LogicalLocations; len(ll) != 1 || ll[0].FullyQualifiedName != "ex/elsewhere" || ll[0].Kind != "package" {
+		t.Errorf("logical locations = %+v", ll)
+	}
+}
+
+func TestSarifReportFingerprintsAreStable(t *testing.T) {
+	v := violation{Rule: ruleLayerDeps, Severity: severityError, Package: "ex/a", Import: "ex/b", File: "a/a.go", Line: 3, Message: "m"}
+	moved := v
+	moved.Line = 30
+
+	fingerprint := func(v violation) string {
+		data, err := sarifReport(newCheckReport("sweep", []violation{v}), func(string) (string, bool) { return "", false })
+		if err != nil {
+			t.Fatal(err)
+		}
+		var log sarifLog
+		if err := json.Unmarshal(data, &log); err != nil {
+			t.Fatal(err)
+		}
+		return log.Runs[0].Results[0].PartialFingerprints["goarchtest/v1"]
+	}
+	if fingerprint(v) != fingerprint(moved) {
+		t.Error("moving a violation to another line changed its fingerprint")
+	}
+}
+
+func TestSarifReportWithoutViolations(t *testing.T) {
+	data, err := sarifReport(newCheckReport("sweep", nil), func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Runs []struct {
+			Results json.RawMessage `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(raw.Runs[0].Results); got != "[]" {
+		t.Errorf("results = %s, want []", got)
+	}
+}
+
+func TestPackageFile(t *testing.T) {
+	graph := loadModule(t, map[string]string{
+		"internal/order/domain/order.go": "package domain\n",
+		"internal/order/domain/item.go":  "package domain\n",
+		"internal/order/domain/gen.go":   "//go:build ignore\n\npackage main\n",
+	})
+
+	tests := []struct {
+		importPath string
+		want       string
+		ok         bool
+	}{
+		{importPath: "example.com/shop/internal/order/domain", want: "internal/order/domain/item.go", ok: true},
+		{importPath: "example.com/shop/internal/order"},
+		{importPath: "example.com/other/internal/order/domain"},
+	}
+	for _, tt := range tests {
+		if got, ok := graph.packageFile(tt.importPath); got != tt.want || ok != tt.ok {
+			t.Errorf("packageFile(%s) = %q, %v, want %q, %v", tt.importPath, got, ok, tt.want, tt.ok)
+		}
+	}
+}
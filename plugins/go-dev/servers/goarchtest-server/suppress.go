@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ignoreDirective acknowledges an intentional violation in code:
+//
+//	//archtest:ignore layer-deps reason="legacy adapter, TICKET-123"
+//
+// In a package doc comment it covers every violation of the package; on
+// its own line it covers the line after its comment group, and after code it
+// covers that line. Several rules are separated by commas.
+const ignoreDirective = "//archtest:ignore"
+
+// Scopes of a suppression.
+const (
+	scopePackage = "package"
+	scopeLine    = "line"
+)
+
+// suppression is one archtest:ignore directive. A directive with a problem,
+// such as a missing reason, suppresses nothing.
+type suppression struct {
+	Rules   []string `json:"rules"`
+	Reason  string   `json:"reason"`
+	Scope   string   `json:"scope"`
+	Package string   `json:"package"`
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	Problem string   `json:"problem,omitempty"`
+
+	// target is the line a line suppression covers.
+	target int
+}
+
+// parseSuppressions returns the archtest:ignore directives of f, which
+// belongs to the package importPath and lives at the module-relative path
+// rel.
+func parseSuppressions(fset *token.FileSet, f *ast.File, rel, importPath string) []suppression {
+	var sups []suppression
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			args, ok := strings.CutPrefix(c.Text, ignoreDirective)
+			if !ok || (args != "" && args[0] != ' ' && args[0] != '\t') {
+				continue
+			}
+
+			line := fset.Position(c.Pos()).Line
+			sup := suppression{Package: importPath, File: rel, Line: line, Scope: scopeLine}
+			sup.Rules, sup.Reason, sup.Problem = parseIgnoreArgs(args)
+			switch {
+			case group == f.Doc:
+				sup.Scope = scopePackage
+			case followsCode(fset, f, c):
+				sup.target = line
+			default:
+				sup.target = fset.Position(group.End()).Line + 1
+			}
+			sups = append(sups, sup)
+		}
+	}
+	return sups
+}
+
+// parseIgnoreArgs parses the comma-separated rule ids and the
+// reason="..." attribute of a directive, and describes what is wrong with
+// them, if anything.
+func parseIgnoreArgs(args string) (rules []string, reason, problem string) {
+	ids, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if ids == "" {
+		return nil, "", "names no rule; expected " + ignoreDirective + ` <rule>[,<rule>] reason="..."`
+	}
+	rules = strings.Split(ids, ",")
+	for _, id := range rules {
+		if !slices.ContainsFunc(allRules, func(r ruleInfo) bool { return r.ID == id }) {
+			return rules, "", fmt.Sprintf("unknown rule %q, expected one of: %s", id, strings.Join(checkedRules(""), ", "))
+		}
+	}
+
+	rest = strings.TrimSpace(rest)
+	value, ok := strings.CutPrefix(rest, "reason=")
+	if !ok {
+		return rules, "", `a reason is required, e.g. reason="legacy adapter, TICKET-123"`
+	}
+	if strings.HasPrefix(value, `"`) {
+		quoted, err := strconv.QuotedPrefix(value)
+		if err != nil {
+			return rules, "", "reason is not a valid quoted string"
+		}
+		reason, _ = strconv.Unquote(quoted)
+		if extra := strings.TrimSpace(value[len(quoted):]); extra != "" {
+			return rules, reason, fmt.Sprintf("unexpected text %q after the reason", extra)
+		}
+	} else {
+		reason = value
+	}
+	if strings.TrimSpace(reason) == "" {
+		return rules, "", `a reason is required, e.g. reason="legacy adapter, TICKET-123"`
+	}
+	return rules, reason, ""
+}
+
+// followsCode reports whether code precedes the comment c on its line.
+func followsCode(fset *token.FileSet, f *ast.File, c *ast.Comment) bool {
+	pos := fset.Position(c.Pos())
+	found := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+		if found || fset.Position(n.Pos()).Line > pos.Line || fset.Position(n.End()).Line < pos.Line {
+			return false
+		}
+		if start := fset.Position(n.Pos()); start.Line == pos.Line && start.Column < pos.Column {
+			found = true
+		}
+		return true
+	})
+	return found
+}
+
+// covers reports whether the suppression applies to v.
+func (s suppression) covers(v violation) bool {
+	if s.Problem != "" || !slices.Contains(s.Rules, v.Rule) {
+		return false
+	}
+	if s.Scope == scopePackage {
+		return v.Package == s.Package
+	}
+	return v.File == s.File && v.Line == s.target
+}
+
+// suppressions returns every archtest:ignore directive of the graph.
+func (g *importGraph) suppressions() []suppression {
+	var sups []suppression
+	for _, pkg := range g.sortedPackages() {
+		for _, f := range pkg.files {
+			sups = append(sups, f.suppressions...)
+		}
+	}
+	return sups
+}
+
+// packageSuppressions returns the directives of the edited file of pkg, the
+// only file the graph parsed, together with the package-wide directives in
+// the doc comments of its other files, which are parsed only up to the
+// package clause.
+func packageSuppressions(graph *importGraph, pkg *packageNode, edited string) ([]suppression, error) {
+	sups := graph.suppressions()
+	entries, err := os.ReadDir(pkg.dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == edited || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(graph.fset, filepath.Join(pkg.dir, name), nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		for _, s := range parseSuppressions(graph.fset, f, path.Join(pkg.relPath, name), pkg.importPath) {
+			if s.Scope == scopePackage {
+				sups = append(sups, s)
+			}
+		}
+	}
+	return sups, nil
+}
+
+// suppressViolations removes the violations that one of sups covers.
+func suppressViolations(sups []suppression, violations []violation) (remaining []violation, suppressed int) {
+	remaining = []violation{}
+	for _, v := range violations {
+		if slices.ContainsFunc(sups, func(s suppression) bool { return s.covers(v) }) {
+			suppressed++
+			continue
+		}
+		remaining = append(remaining, v)
+	}
+	return remaining, suppressed
+}
+
+// unsuppressed returns the violations no directive of graph suppresses.
+func unsuppressed(graph *importGraph, violations []violation) []violation {
+	remaining, _ := suppressViolations(graph.suppressions(), violations)
+	return remaining
+}
+
+// ignoreSuppressed removes the violations that directives suppress from
+// report and counts them in report.Ignored.
+func ignoreSuppressed(graph *importGraph, report *checkReport) {
+	remaining, ignored := suppressViolations(graph.suppressions(), report.Violations)
+	if ignored == 0 {
+		return
+	}
+	*report = newCheckReport(report.Check, remaining)
+	report.Ignored = ignored
+}
+
+// suppressionUse is a directive together with the violations it suppresses.
+type suppressionUse struct {
+	suppression
+	Suppressed []violation `json:"suppressed"`
+}
+
+// suppressionReport is the structured result of list_suppressions. It
+// passes when every directive is valid; unused directives are only counted.
+type suppressionReport struct {
+	Check        string           `json:"check"`
+	Passed       bool             `json:"passed"`
+	Summary      suppressionCount `json:"summary"`
+	Suppressions []suppressionUse `json:"suppressions"`
+}
+
+type suppressionCount struct {
+	Total   int `json:"total"`
+	Active  int `json:"active"`
+	Unused  int `json:"unused"`
+	Invalid int `json:"invalid"`
+}
+
+// listSuppressions matches every directive of the graph, or only those
+// naming rule, against the violations of a full sweep.
+func listSuppressions(graph *importGraph, rules *archRules, rule string) *suppressionReport {
+	_, all := runAllRules(graph, rules)
+	all = newCheckReport("list_suppressions", all).Violations
+
+	report := &suppressionReport{Check: "list_suppressions", Passed: true, Suppressions: []suppressionUse{}}
+	for _, s := range graph.suppressions() {
+		if rule != "" && !slices.Contains(s.Rules, rule) {
+			continue
+		}
+		use := suppressionUse{suppression: s, Suppressed: []violation{}}
+		for _, v := range all {
+			if s.covers(v) {
+				use.Suppressed = append(use.Suppressed, v)
+			}
+		}
+		report.Suppressions = append(report.Suppressions, use)
+
+		report.Summary.Total++
+		switch {
+		case s.Problem != "":
+			report.Summary.Invalid++
+			report.Passed = false
+		case len(use.Suppressed) == 0:
+			report.Summary.Unused++
+		default:
+			report.Summary.Active++
+		}
+	}
+	return report
+}
+
+func (r *suppressionReport) String() string {
+	var b strings.Builder
+	if r.Summary.Total == 0 {
+		b.WriteString("✅ No archtest:ignore directives")
+		return b.String()
+	}
+	if r.Passed {
+		b.WriteString("✅ ")
+	} else {
+		b.WriteString("❌ ")
+	}
+	fmt.Fprintf(&b, "%d archtest:ignore directive(s): %d active, %d unused, %d invalid\n",
+		r.Summary.Total, r.Summary.Active, r.Summary.Unused, r.Summary.Invalid)
+
+	for _, s := range r.Suppressions {
+		where := fmt.Sprintf("%s:%d", s.File, s.Line)
+		if s.Scope == scopePackage {
+			where += " (package " + s.Package + ")"
+		}
+		fmt.Fprintf(&b, "\n- %s %s", where, strings.Join(s.Rules, ","))
+		if s.Reason != "" {
+			fmt.Fprintf(&b, ": %s", s.Reason)
+		}
+		switch {
+		case s.Problem != "":
+			fmt.Fprintf(&b, "\n  ❌ invalid, suppresses nothing: %s", s.Problem)
+		case len(s.Suppressed) == 0:
+			b.WriteString("\n  ⚠️ unused: no such violation here, remove the directive")
+		default:
+			for _, v := range s.Suppressed {
+				fmt.Fprintf(&b, "\n  - %s", v)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseIgnoreArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		rules   []string
+		reason  string
+		problem string // substring of the expected problem, "" for none
+	}{
+		{
+			name:   "quoted reason",
+			args:   ` layer-deps reason="legacy adapter, TICKET-123"`,
+			rules:  []string{"layer-deps"},
+			reason: "legacy adapter, TICKET-123",
+		},
+		{
+			name:   "unquoted reason",
+			args:   ` naming reason=generated`,
+			rules:  []string{"naming"},
+			reason: "generated",
+		},
+		{
+			name:   "comma list",
+			args:   ` layer-deps,domain-isolation reason="shared wiring"`,
+			rules:  []string{"layer-deps", "domain-isolation"},
+			reason: "shared wiring",
+		},
+		{
+			name:    "no rule",
+			args:    "",
+			problem: "names no rule",
+		},
+		{
+			name:    "missing reason",
+			args:    ` layer-deps`,
+			rules:   []string{"layer-deps"},
+			problem: "a reason is required",
+		},
+		{
+			name:    "empty reason",
+			args:    ` layer-deps reason="  "`,
+			rules:   []string{"layer-deps"},
+			problem: "a reason is required",
+		},
+		{
+			name:    "unterminated reason",
+			args:    ` layer-deps reason="legacy`,
+			rules:   []string{"layer-deps"},
+			problem: "not a valid quoted string",
+		},
+		{
+			name:    "trailing text",
+			args:    ` layer-deps reason="legacy" until v2`,
+			rules:   []string{"layer-deps"},
+			reason:  "legacy",
+			problem: `unexpected text "until v2"`,
+		},
+		{
+			name:    "unknown rule",
+			args:    ` layer-deps,layering reason="legacy"`,
+			rules:   []string{"layer-deps", "layering"},
+			problem: `unknown rule "layering"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, reason, problem := parseIgnoreArgs(tt.args)
+			if !slices.Equal(rules, tt.rules) {
+				t.Errorf("rules = %q, want %q", rules, tt.rules)
+			}
+			if reason != tt.reason {
+				t.Errorf("reason = %q, want %q", reason, tt.reason)
+			}
+			if tt.problem == "" && problem != "" || !strings.Contains(problem, tt.problem) {
+				t.Errorf("problem = %q, want %q", problem, tt.problem)
+			}
+		})
+	}
+}
+
+func TestParseSuppressions(t *testing.T) {
+	const src = `//archtest:ignore naming reason="package-wide"
+package order
+
+import (
+	"example.com/shop/internal/billing/domain" //archtest:ignore domain-isolation reason="trailing"
+
+	//archtest:ignore layer-deps reason="own line"
+	// A second comment line in the same group.
+	"example.com/shop/internal/order/infrastructure"
+)
+
+//archtest:ignorelayer-deps reason="not a directive"
+var _ = domain.X
+
+var _ = infrastructure.Y //archtest:ignore layer-deps
+`
+
+	tests := []struct {
+		line   int
+		scope  string
+		target int
+		rules  []string
+		broken bool
+	}{
+		{line: 1, scope: scopePackage, rules: []string{"naming"}},
+		{line: 5, scope: scopeLine, target: 5, rules: []string{"domain-isolation"}},
+		{line: 7, scope: scopeLine, target: 9, rules: []string{"layer-deps"}},
+		{line: 15, scope: scopeLine, target: 15, rules: []string{"layer-deps"}, broken: true},
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "order.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sups := parseSuppressions(fset, f, "internal/order/order.go", "example.com/shop/internal/order")
+	if len(sups) != len(tests) {
+		t.Fatalf("got %d suppressions, want %d: %+v", len(sups), len(tests), sups)
+	}
+	for i, tt := range tests {
+		s := sups[i]
+		if s.Line != tt.line || s.Scope != tt.scope || s.target != tt.target || !slices.Equal(s.Rules, tt.rules) || (s.Problem != "") != tt.broken {
+			t.Errorf("suppression %d = %+v (target %d), want line %d, scope %s, target %d, rules %q, broken %v",
+				i, s, s.target, tt.line, tt.scope, tt.target, tt.rules, tt.broken)
+		}
+		if s.File != "internal/order/order.go" || s.Package != "example.com/shop/internal/order" {
+			t.Errorf("suppression %d is at %s in %s", i, s.File, s.Package)
+		}
+	}
+}
+
+func TestSuppressionCovers(t *testing.T) {
+	pkg := suppression{Rules: []string{"naming"}, Scope: scopePackage, Package: "ex/order"}
+	line := suppression{Rules: []string{"layer-deps", "naming"}, Scope: scopeLine, File: "order/a.go", target: 7}
+	invalid := line
+	invalid.Problem = "a reason is required"
+
+	tests := []struct {
+		name string
+		s    suppression
+		v    violation
+		want bool
+	}{
+		{"package scope", pkg, violation{Rule: "naming", Package: "ex/order", File: "order/b.go", Line: 3}, true},
+		{"package scope, other rule", pkg, violation{Rule: "layer-deps", Package: "ex/order"}, false},
+		{"package scope, other package", pkg, violation{Rule: "naming", Package: "ex/order/domain"}, false},
+		{"target line", line, violation{Rule: "naming", File: "order/a.go", Line: 7}, true},
+		{"other line", line, violation{Rule: "naming", File: "order/a.go", Line: 8}, false},
+		{"other file", line, violation{Rule: "naming", File: "order/b.go", Line: 7}, false},
+		{"invalid directive", invalid, violation{Rule: "naming", File: "order/a.go", Line: 7}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.covers(tt.v); got != tt.want {
+				t.Errorf("covers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// architectureTestsDir holds the user-defined goarchtest suites.
+const architectureTestsDir = "test/architecture"
+
+// defaultTestTimeout bounds a run of the user-defined tests so that a hung
+// test cannot block the server.
+const defaultTestTimeout = 5 * time.Minute
+
+// testOptions narrows and bounds a run of the user-defined tests.
+type testOptions struct {
+	run     string
+	pkg     string
+	timeout time.Duration
+}
+
+// testRun is the outcome of running the user-defined architecture tests.
+type testRun struct {
+	Ran      bool             `json:"ran"`
+	Passed   bool             `json:"passed"`
+	TimedOut bool             `json:"timedOut,omitempty"`
+	Tests    []testCaseResult `json:"tests"`
+	Packages []packageResult  `json:"packages"`
+	Output   string           `json:"output,omitempty"`
+}
+
+// runArchitectureTests runs the suites in test/architecture, or the package
+// pattern in opts, with `go test -json`. A missing directory is reported as
+// not run rather than as a failure. The run stops when ctx is cancelled or
+// the timeout elapses.
+func (s *GoArchTestServer) runArchitectureTests(ctx context.Context, opts testOptions) (testRun, error) {
+	pkg := opts.pkg
+	if pkg == "" {
+		if _, err := os.Stat(filepath.Join(s.projectRoot, architectureTestsDir)); err != nil {
+			return testRun{}, nil
+		}
+		pkg = "./" + architectureTestsDir + "/..."
+	}
+	if strings.HasPrefix(pkg, "-") {
+		return testRun{}, fmt.Errorf("invalid package pattern %q", pkg)
+	}
+
+	timeout := opts.timeout
+	if timeout <= 0 {
+		timeout = defaultTestTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// -timeout makes the test binary itself give up, so it does not outlive
+	// the go command when the context kills it.
+	args := []string{"test", "-json", "-count=1", "-timeout", timeout.String()}
+	if opts.run != "" {
+		args = append(args, "-run", opts.run)
+	}
+	args = append(args, pkg)
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = s.projectRoot
+	cmd.WaitDelay = 5 * time.Second
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+
+	run := testRun{Ran: true, Passed: err == nil}
+	var other string
+	run.Tests, run.Packages, other = parseTestEvents(stdout)
+	run.Output = strings.TrimSpace(other + stderr.String())
+	if run.Tests == nil {
+		run.Tests = []testCaseResult{}
+	}
+	if run.Packages == nil {
+		run.Packages = []packageResult{}
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		run.Passed = false
+		if errors.Is(ctxErr, context.DeadlineExceeded) {
+			run.TimedOut = true
+			return run, nil
+		}
+		return run, ctxErr
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return run, fmt.Errorf("running go test: %w", err)
+	}
+	return run, nil
+}
+
+// sweepReport is the consolidated result of every built-in rule plus the
+// user-defined architecture tests.
+type sweepReport struct {
+	checkReport
+	Domains []string `json:"domains"`
+	Tests   testRun  `json:"tests"`
+}
+
+// runAllRules evaluates the whole rule set across every discovered domain:
+// each layer's dependencies, isolation between every pair of domains, every
+// naming rule, purity, import cycles and the fit of ports and adapters.
+//
+// The sweep type-checks the module, so its result is kept with the graph:
+// the baseline and suppression bookkeeping of every single-rule check needs
+// it, and the cache hands out the same graph until a file changes.
+func runAllRules(graph *importGraph, rules *archRules) ([]string, []violation) {
+	graph.sweepMu.Lock()
+	defer graph.sweepMu.Unlock()
+
+	if graph.sweptRules != rules {
+		domains := discoveredDomains(graph, rules)
+
+		violations := packageRuleViolations(graph, rules, domains)
+		violations = append(violations, cycleViolations(graph, detectCycles(graph, rules, "all"))...)
+		_, portProblems := portViolations(graph, rules, "")
+		violations = append(violations, portProblems...)
+
+		graph.sweptRules, graph.sweptIn, graph.swept = rules, domains, violations
+	}
+	// Callers sort and filter the result in place.
+	return slices.Clone(graph.sweptIn), slices.Clone(graph.swept)
+}
+
+// packageRuleViolations evaluates the rules that are attributed to a single
+// package: every layer's dependencies, isolation between every pair of
+// domains, every naming rule, the purity of the pure layers, the external
+// module policies and the workspace module rules.
+func packageRuleViolations(graph *importGraph, rules *archRules, domains []string) []violation {
+	var violations []violation
+	for _, layer := range rules.layerNames() {
+		violations = append(violations, layerViolations(graph, rules, layer, "")...)
+	}
+	violations = append(violations, protectedViolations(graph, rules, "", "")...)
+	for _, source := range domains {
+		for _, target := range domains {
+			if source != target {
+				violations = append(violations, domainIsolationViolations(graph, rules, source, target)...)
+			}
+		}
+	}
+	for _, rule := range rules.Naming {
+		violations = append(violations, namingViolations(graph, rules, rule)...)
+	}
+	violations = append(violations, purityViolations(graph, rules)...)
+	violations = append(violations, externalViolations(graph, rules)...)
+	violations = append(violations, moduleViolations(graph, rules)...)
+	return violations
+}
+
+// discoveredDomains lists every bounded context that owns at least one
+// package, whether or not the package belongs to a layer.
+func discoveredDomains(graph *importGraph, rules *archRules) []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, pkg := range graph.sortedPackages() {
+		if d, _ := rules.classify(pkg.relPath); d != "" && !seen[d] {
+			seen[d] = true
+			domains = append(domains, d)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	transportStdio = "stdio"
+	transportHTTP  = "http"
+	transportSSE   = "sse"
+)
+
+const (
+	defaultAddr = "localhost:8080"
+	// tokenEnv supplies the bearer token when -token is not given, which
+	// keeps the secret out of the process list.
+	tokenEnv = "GOARCHTEST_TOKEN"
+	// httpEndpoint is where the streamable HTTP transport accepts requests.
+	httpEndpoint = "/mcp"
+)
+
+// httpTransport is implemented by the streamable HTTP and SSE servers of
+// mcp-go.
+type httpTransport interface {
+	Start(addr string) error
+	Shutdown(ctx context.Context) error
+}
+
+// serveHTTP serves mcpServer over the streamable HTTP or SSE transport on
+// addr until the process receives SIGINT or SIGTERM. A single instance keeps
+// its import graph cache warm across every connected session. When token is
+// set, requests must carry it as a bearer token.
+func serveHTTP(mcpServer *server.MCPServer, transport, addr, token string, stderr io.Writer) error {
+	srv := &http.Server{Addr: addr, ReadHeaderTimeout: 10 * time.Second}
+
+	var t httpTransport
+	var endpoint string
+	switch transport {
+	case transportHTTP:
+		t = server.NewStreamableHTTPServer(mcpServer, server.WithStreamableHTTPServer(srv))
+		mux := http.NewServeMux()
+		mux.Handle(httpEndpoint, requireBearer(token, t.(http.Handler)))
+		srv.Handler = mux
+		endpoint = httpEndpoint
+	case transportSSE:
+		t = server.NewSSEServer(mcpServer, server.WithHTTPServer(srv))
+		srv.Handler = requireBearer(token, t.(http.Handler))
+		endpoint = "/sse"
+	default:
+		return fmt.Errorf("unsupported transport %q", transport)
+	}
+
+	if token == "" && !isLoopback(addr) {
+		fmt.Fprintf(stderr, "Warning: serving on %s without a bearer token; set -token or %s\n", addr, tokenEnv)
+	}
+	fmt.Fprintf(stderr, "GoArchTest MCP server listening on http://%s%s\n", addr, endpoint)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- t.Start(addr) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := t.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// requireBearer rejects requests whose Authorization header does not carry
+// token. An empty token disables the check.
+func requireBearer(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+serverName+`"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopback reports whether addr only listens on the loopback interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
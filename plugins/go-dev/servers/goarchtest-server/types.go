@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/types"
+	"path"
+	"strings"
+	"sync"
+)
+
+// stdImporter loads standard library packages from the toolchain's export
+// data. It is shared by every graph so that each package is loaded once per
+// process; go/importer importers are not safe for concurrent use.
+var stdImporter = struct {
+	sync.Mutex
+	types.Importer
+}{Importer: importer.Default()}
+
+// typedPackages type-checks every package of the graph and returns them by
+// import path. The result is computed once per graph. Packages that the
+// graph this one replaced in the cache already checked are reused when
+// neither they nor the module packages they depend on changed.
+//
+// Packages of the module are resolved from each other. Standard library
+// packages come from the toolchain; third-party packages are replaced with
+// empty stand-ins so that the project does not need its dependencies
+// downloaded. Type errors are ignored: declarations that refer to a missing
+// package simply get invalid types.
+func (g *importGraph) typedPackages() map[string]*types.Package {
+	g.typesMu.Lock()
+	defer g.typesMu.Unlock()
+
+	if g.typed == nil {
+		imp := &moduleImporter{
+			graph:    g,
+			previous: g.previous.typedPackagesIfChecked(),
+			checked:  make(map[string]*types.Package),
+			checking: make(map[string]bool),
+			external: make(map[string]*types.Package),
+		}
+		for _, pkg := range g.sortedPackages() {
+			imp.check(pkg)
+		}
+		g.typed, g.previous = imp.checked, nil
+	}
+	return g.typed
+}
+
+// typedPackagesIfChecked returns the type-checked packages of g when they
+// were computed, or else those of the graph g replaced, so that a chain of
+// graphs nobody type-checked does not keep old graphs alive. g may be nil.
+func (g *importGraph) typedPackagesIfChecked() *importGraph {
+	if g == nil {
+		return nil
+	}
+	g.typesMu.Lock()
+	defer g.typesMu.Unlock()
+	if g.typed != nil {
+		return g
+	}
+	return g.previous
+}
+
+// moduleImporter type-checks the packages of a graph on demand, in
+// dependency order.
+type moduleImporter struct {
+	graph    *importGraph
+	previous *importGraph
+	checked  map[string]*types.Package
+	checking map[string]bool
+	external map[string]*types.Package
+}
+
+func (m *moduleImporter) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := m.graph.packages[importPath]; ok {
+		if m.checking[importPath] {
+			return nil, fmt.Errorf("import cycle through %s", importPath)
+		}
+		return m.check(pkg), nil
+	}
+	if pkg, ok := m.external[importPath]; ok {
+		return pkg, nil
+	}
+
+	var pkg *types.Package
+	if isStdLib(importPath) {
+		stdImporter.Lock()
+		pkg, _ = stdImporter.Import(importPath)
+		stdImporter.Unlock()
+	}
+	if pkg == nil {
+		pkg = types.NewPackage(importPath, guessPackageName(importPath))
+		pkg.MarkComplete()
+	}
+	m.external[importPath] = pkg
+	return pkg, nil
+}
+
+func (m *moduleImporter) check(pkg *packageNode) *types.Package {
+	if checked, ok := m.checked[pkg.importPath]; ok {
+		return checked
+	}
+	m.checking[pkg.importPath] = true
+	defer delete(m.checking, pkg.importPath)
+
+	if reused := m.reusable(pkg); reused != nil {
+		m.checked[pkg.importPath] = reused
+		return reused
+	}
+
+	files := make([]*ast.File, 0, len(pkg.files))
+	for _, f := range pkg.files {
+		// Files whose package clause does not parse would name the
+		// package "" and make the checker drop every other file.
+		if f.ast.Name.Name != "" {
+			files = append(files, f.ast)
+		}
+	}
+	conf := types.Config{
+		Importer:    m,
+		Error:       func(error) {},
+		FakeImportC: true,
+	}
+	checked, _ := conf.Check(pkg.importPath, m.graph.fset, files, nil)
+	m.checked[pkg.importPath] = checked
+	return checked
+}
+
+// reusable returns the package the previous graph checked from the same
+// files, provided that every module package it imported was reused as well,
+// so that its types refer to the packages of this graph.
+func (m *moduleImporter) reusable(pkg *packageNode) *types.Package {
+	if m.previous == nil || m.previous.packages[pkg.importPath] != pkg {
+		return nil
+	}
+	typed := m.previous.typed[pkg.importPath]
+	if typed == nil {
+		return nil
+	}
+	for _, imported := range typed.Imports() {
+		dep, ok := m.graph.packages[imported.Path()]
+		if !ok {
+			// Removed module packages change what the import resolves to.
+			if _, ok := m.previous.packages[imported.Path()]; ok {
+				return nil
+			}
+			continue
+		}
+		if m.checking[dep.importPath] || m.check(dep) != imported {
+			return nil
+		}
+	}
+	return typed
+}
+
+// guessPackageName derives the name a third-party package is most likely
+// declared with from its import path, skipping major version suffixes and
+// the usual go- prefixes and .go suffixes.
+func guessPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, ".go")
+	return strings.NewReplacer("-", "", ".", "").Replace(name)
+}
+
+// namedTypes returns the types declared at the top level of pkg, excluding
+// aliases, sorted by name.
+func namedTypes(pkg *types.Package) []*types.TypeName {
+	if pkg == nil {
+		return nil
+	}
+	var named []*types.TypeName
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if tn, ok := scope.Lookup(name).(*types.TypeName); ok && !tn.IsAlias() {
+			named = append(named, tn)
+		}
+	}
+	return named
+}
+
+// isInterface reports whether tn declares an interface type.
+func isInterface(tn *types.TypeName) bool {
+	return types.IsInterface(tn.Type())
+}
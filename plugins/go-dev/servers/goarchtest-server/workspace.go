@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const workspaceFileName = "go.work"
+
+// workspace is a go.work workspace and the modules its use directives list.
+type workspace struct {
+	root    string
+	modules []*workspaceModule
+}
+
+// workspaceModule is one module of a workspace. The server of the workspace
+// analyzes it with a server of its own, so every module keeps its own
+// graph cache, rules and baseline.
+type workspaceModule struct {
+	Path string `json:"module"`
+	Dir  string `json:"dir"`
+
+	workspace *workspace
+	server    *GoArchTestServer
+}
+
+// loadWorkspace reads the go.work file in root. It returns nil without error
+// when root holds none.
+func loadWorkspace(root string) (*workspace, error) {
+	p := filepath.Join(root, workspaceFileName)
+	if _, err := os.Stat(p); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	dirs, err := readDirective(p, "use")
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &workspace{root: root}
+	for _, dir := range dirs {
+		abs := dir
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(root, dir)
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: use %s: %w", workspaceFileName, dir, err)
+		}
+		modulePath, err := readModulePath(filepath.Join(abs, "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: use %s: %w", workspaceFileName, dir, err)
+		}
+		ws.modules = append(ws.modules, &workspaceModule{Path: modulePath, Dir: filepath.ToSlash(rel), workspace: ws})
+	}
+	if len(ws.modules) == 0 {
+		return nil, fmt.Errorf("%s uses no module", p)
+	}
+	return ws, nil
+}
+
+// workspaceModuleAt returns the module at root when the nearest go.work at
+// or above root uses it, as the go command would, or nil.
+func workspaceModuleAt(root string) *workspaceModule {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, workspaceFileName)); err == nil {
+			ws, err := loadWorkspace(d)
+			if err != nil {
+				return nil
+			}
+			for _, m := range ws.modules {
+				if filepath.Join(d, m.Dir) == abs {
+					return m
+				}
+			}
+			return nil
+		}
+		if filepath.Dir(d) == d {
+			return nil
+		}
+	}
+}
+
+// paths returns the module paths of the workspace.
+func (w *workspace) paths() []string {
+	paths := make([]string, len(w.modules))
+	for i, m := range w.modules {
+		paths[i] = m.Path
+	}
+	return paths
+}
+
+// dirs returns the module directories of the workspace.
+func (w *workspace) dirs() []string {
+	dirs := make([]string, len(w.modules))
+	for i, m := range w.modules {
+		dirs[i] = m.Dir
+	}
+	return dirs
+}
+
+// selectModules returns the module named by selector, a module path or a
+// directory relative to the workspace root, or every module when selector
+// is empty.
+func (w *workspace) selectModules(selector string) ([]*workspaceModule, error) {
+	if selector == "" {
+		return w.modules, nil
+	}
+	dir := path.Clean(filepath.ToSlash(selector))
+	for _, m := range w.modules {
+		if m.Path == selector || m.Dir == dir {
+			return []*workspaceModule{m}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown module %q, expected a module path or one of: %s", selector, strings.Join(w.dirs(), ", "))
+}
+
+// newModuleServer returns the server that analyzes m. It registers no MCP
+// handlers: the server of the workspace dispatches to it.
+func newModuleServer(m *workspaceModule) *GoArchTestServer {
+	root := filepath.Join(m.workspace.root, m.Dir)
+	s := &GoArchTestServer{
+		projectRoot: root,
+		cache:       newGraphCache(root),
+		module:      m,
+	}
+	m.server = s
+	return s
+}
+
+// loadModuleRules reads the rules of the workspace module at root. A module
+// without a rules file of its own uses the one in the workspace root, and the
+// module rules of the workspace root apply to every module.
+func loadModuleRules(root string, ws *workspace) (*archRules, []string, error) {
+	rules, problems, err := loadRules(ws.root)
+	if err != nil || len(problems) > 0 {
+		for i := range problems {
+			problems[i] = findRulesFile(ws.root) + ": " + problems[i]
+		}
+		return nil, problems, err
+	}
+
+	if filepath.Clean(root) != filepath.Clean(ws.root) && findRulesFile(root) != "" {
+		own, problems, err := loadRules(root)
+		if err != nil || len(problems) > 0 {
+			return nil, problems, err
+		}
+		own.Modules = slices.Concat(rules.Modules, own.Modules)
+		rules = own
+	}
+	rules.workspace = ws.paths()
+	return rules, nil, nil
+}
+
+// renderWorkspaceReport merges the check results of workspace modules into
+// one CI report, as code scanning expects a single run per tool. Files and
+// package directories become relative to the workspace root, and JUnit test
+// cases are named after the module directory and the domain.
+func renderWorkspaceReport(format string, checks []moduleCheck, domain string) ([]byte, error) {
+	byPackage := make(map[string]moduleCheck)
+	var violations []violation
+	var domains []string
+	for _, c := range checks {
+		for _, pkg := range c.graph.packages {
+			byPackage[pkg.importPath] = c
+		}
+		for _, v := range c.report.Violations {
+			if v.File != "" {
+				v.File = path.Join(c.module.Dir, v.File)
+			}
+			violations = append(violations, v)
+		}
+		for _, d := range c.checkedDomains(domain) {
+			domains = append(domains, path.Join(c.module.Dir, d))
+		}
+	}
+
+	report := newCheckReport("check", violations)
+	switch format {
+	case "sarif":
+		return sarifReport(report, func(importPath string) (string, bool) {
+			c, ok := byPackage[importPath]
+			if !ok {
+				return "", false
+			}
+			file, ok := c.graph.packageFile(importPath)
+			return path.Join(c.module.Dir, file), ok
+		})
+	case "junit":
+		return junitReport(report, func(importPath string) string {
+			c, ok := byPackage[importPath]
+			if !ok {
+				return ""
+			}
+			rel, _ := c.graph.relImport(importPath)
+			if d, _ := c.rules.classify(rel); d != "" {
+				return path.Join(c.module.Dir, d)
+			}
+			return ""
+		}, domains)
+	}
+	return nil, fmt.Errorf("unsupported report format %q", format)
+}
+
+// toolHandler is a tool handler bound to the server of one module.
+type toolHandler func(s *GoArchTestServer, ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
+
+// moduleResult is the outcome of a tool on one workspace module.
+type moduleResult struct {
+	Module string `json:"module"`
+	Dir    string `json:"dir"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+	Result any    `json:"result,omitempty"`
+}
+
+// workspaceResult groups the results of a tool by workspace module.
+type workspaceResult struct {
+	Check   string         `json:"check"`
+	Passed  bool           `json:"passed"`
+	Modules []moduleResult `json:"modules"`
+}
+
+// perModule runs handler on the server itself outside a workspace. In a
+// go.work workspace it runs handler on the module named by the module
+// argument, or on every module, and groups the results by module. Report
+// documents follow the text, one per module and relative to its directory.
+func (s *GoArchTestServer) perModule(handler toolHandler) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if s.workspace == nil && request.GetString("module", "") == "" {
+			return handler(s, ctx, request)
+		}
+		modules, err := s.selectModules(request.GetString("module", ""))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		grouped := workspaceResult{Check: request.Params.Name, Passed: true, Modules: []moduleResult{}}
+		var sections, failed []string
+		var documents []mcp.Content
+		for _, m := range modules {
+			result, err := handler(m.server, ctx, request)
+			if err != nil {
+				return nil, err
+			}
+			text := ""
+			if len(result.Content) > 0 {
+				if tc, ok := mcp.AsTextContent(result.Content[0]); ok {
+					text = tc.Text
+				}
+				documents = append(documents, result.Content[1:]...)
+			}
+
+			r := moduleResult{Module: m.Path, Dir: m.Dir, Passed: resultPassed(result), Result: result.StructuredContent}
+			if result.IsError {
+				r.Error = text
+			}
+			if !r.Passed {
+				grouped.Passed = false
+				failed = append(failed, m.Dir)
+			}
+			grouped.Modules = append(grouped.Modules, r)
+			sections = append(sections, fmt.Sprintf("📦 %s (%s)\n%s", m.Path, m.Dir, text))
+		}
+
+		headline := fmt.Sprintf("✅ %d workspace module(s) passed", len(modules))
+		if !grouped.Passed {
+			headline = fmt.Sprintf("❌ %d of %d workspace module(s) failed: %s", len(failed), len(modules), strings.Join(failed, ", "))
+		}
+		result := mcp.NewToolResultStructured(grouped, headline+"\n\n"+strings.Join(sections, "\n\n"))
+		result.Content = append(result.Content, documents...)
+		return result, nil
+	}
+}
+
+// resultPassed reports whether a tool result succeeded: it is no error and
+// its structured content, if any, reports neither passed nor valid as false.
+func resultPassed(result *mcp.CallToolResult) bool {
+	if result.IsError {
+		return false
+	}
+	data, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		return true
+	}
+	var outcome struct {
+		Passed *bool `json:"passed"`
+		Valid  *bool `json:"valid"`
+	}
+	if json.Unmarshal(data, &outcome) != nil {
+		return true
+	}
+	return (outcome.Passed == nil || *outcome.Passed) && (outcome.Valid == nil || *outcome.Valid)
+}
+
+// selectModules selects workspace modules for a module argument, which
+// outside a workspace is an error.
+func (s *GoArchTestServer) selectModules(selector string) ([]*workspaceModule, error) {
+	if s.workspace == nil {
+		return nil, fmt.Errorf("%s is not a %s workspace, so there is no module %q to select", s.projectRoot, workspaceFileName, selector)
+	}
+	return s.workspace.selectModules(selector)
+}
+
+// moduleServers returns the servers of the modules named by selector in a
+// workspace, or the server itself outside one.
+func (s *GoArchTestServer) moduleServers(selector string) ([]*GoArchTestServer, error) {
+	if s.workspace == nil && selector == "" {
+		return []*GoArchTestServer{s}, nil
+	}
+	modules, err := s.selectModules(selector)
+	if err != nil {
+		return nil, err
+	}
+	servers := make([]*GoArchTestServer, len(modules))
+	for i, m := range modules {
+		servers[i] = m.server
+	}
+	return servers, nil
+}
+
+// moduleOption lets a tool analyze a single module of a go.work workspace.
+func moduleOption() mcp.ToolOption {
+	return mcp.WithString("module",
+		mcp.Description("Optional: in a go.work workspace, the module to analyze, by module path or directory. Omit to analyze every module and group the results by module"),
+	)
+}
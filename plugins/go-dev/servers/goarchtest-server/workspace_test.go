@@ -0,0 +1,149 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadWorkspace(t *testing.T) {
+	modules := map[string]string{
+		"billing/go.mod":            "module example.com/billing\n",
+		"order/go.mod":              "module \"example.com/order\" // the core\n",
+		"libs/shared kernel/go.mod": "module example.com/shared\n",
+	}
+
+	tests := []struct {
+		name  string
+		work  string
+		paths []string
+		dirs  []string
+		err   string // substring of the expected error, "" for none
+	}{
+		{
+			name:  "use block",
+			work:  "go 1.22\n\nuse (\n\t./billing\n\t./order // the core\n)\n",
+			paths: []string{"example.com/billing", "example.com/order"},
+			dirs:  []string{"billing", "order"},
+		},
+		{
+			name:  "single-line uses and a quoted directory",
+			work:  "go 1.22\n\nuse ./order\nuse \"./libs/shared kernel\"\n",
+			paths: []string{"example.com/order", "example.com/shared"},
+			dirs:  []string{"order", "libs/shared kernel"},
+		},
+		{
+			name:  "root module",
+			work:  "go 1.22\n\nuse .\n",
+			paths: []string{"example.com/root"},
+			dirs:  []string{"."},
+		},
+		{
+			name: "use of a directory without go.mod",
+			work: "go 1.22\n\nuse ./missing\n",
+			err:  "use ./missing",
+		},
+		{
+			name: "no use directive",
+			work: "go 1.22\n",
+			err:  "uses no module",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, modules)
+			writeFiles(t, root, map[string]string{"go.mod": "module example.com/root\n", "go.work": tt.work})
+
+			ws, err := loadWorkspace(root)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ws.paths(), tt.paths) {
+				t.Errorf("paths = %q, want %q", ws.paths(), tt.paths)
+			}
+			if !slices.Equal(ws.dirs(), tt.dirs) {
+				t.Errorf("dirs = %q, want %q", ws.dirs(), tt.dirs)
+			}
+		})
+	}
+}
+
+func TestLoadWorkspaceWithoutGoWork(t *testing.T) {
+	ws, err := loadWorkspace(t.TempDir())
+	if ws != nil || err != nil {
+		t.Errorf("loadWorkspace = %v, %v, want nil, nil", ws, err)
+	}
+}
+
+func TestWorkspaceModuleAt(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.work":         "go 1.22\n\nuse ./svc/a\n",
+		"svc/a/go.mod":    "module example.com/a\n",
+		"svc/b/go.mod":    "module example.com/b\n",
+		"tools/go.mod":    "module example.com/tools\n",
+		"tools/x/go.work": "go 1.22\n\nuse .\n",
+		"tools/x/go.mod":  "module example.com/x\n",
+	})
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"svc/a", "example.com/a"},
+		{"svc/b", ""},
+		{"tools/x", "example.com/x"},
+	}
+	for _, tt := range tests {
+		m := workspaceModuleAt(filepath.Join(root, filepath.FromSlash(tt.dir)))
+		got := ""
+		if m != nil {
+			got = m.Path
+		}
+		if got != tt.want {
+			t.Errorf("workspaceModuleAt(%s) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestSelectModules(t *testing.T) {
+	ws := &workspace{modules: []*workspaceModule{
+		{Path: "example.com/billing", Dir: "billing"},
+		{Path: "example.com/order", Dir: "services/order"},
+	}}
+
+	tests := []struct {
+		selector string
+		want     []string
+		err      bool
+	}{
+		{"", []string{"example.com/billing", "example.com/order"}, false},
+		{"example.com/order", []string{"example.com/order"}, false},
+		{"services/order", []string{"example.com/order"}, false},
+		{"./services/order/", []string{"example.com/order"}, false},
+		{"payments", nil, true},
+	}
+	for _, tt := range tests {
+		mods, err := ws.selectModules(tt.selector)
+		if (err != nil) != tt.err {
+			t.Errorf("selectModules(%q) error = %v", tt.selector, err)
+			continue
+		}
+		var got []string
+		for _, m := range mods {
+			got = append(got, m.Path)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("selectModules(%q) = %q, want %q", tt.selector, got, tt.want)
+		}
+	}
+}
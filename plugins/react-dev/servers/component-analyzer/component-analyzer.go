@@ -2,11 +2,20 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -517,17 +526,126 @@ func checkA11yIssues(content string) []string {
 	return issues
 }
 
+const (
+	defaultAddr = "localhost:8081"
+	// tokenEnv supplies the bearer token when -token is not given.
+	tokenEnv = "COMPONENT_ANALYZER_TOKEN"
+)
+
+// serveHTTP serves the MCP server over the streamable HTTP ("http") or SSE
+// ("sse") transport until the process receives SIGINT or SIGTERM.
+func (s *ComponentAnalyzer) serveHTTP(transport, addr, token string) error {
+	srv := &http.Server{Addr: addr, ReadHeaderTimeout: 10 * time.Second}
+
+	var t interface {
+		Start(addr string) error
+		Shutdown(ctx context.Context) error
+	}
+	endpoint := "/mcp"
+	switch transport {
+	case "http":
+		httpServer := server.NewStreamableHTTPServer(s.mcpServer, server.WithStreamableHTTPServer(srv))
+		mux := http.NewServeMux()
+		mux.Handle(endpoint, requireBearer(token, httpServer))
+		srv.Handler = mux
+		t = httpServer
+	case "sse":
+		sseServer := server.NewSSEServer(s.mcpServer, server.WithHTTPServer(srv))
+		srv.Handler = requireBearer(token, sseServer)
+		t = sseServer
+		endpoint = "/sse"
+	default:
+		return fmt.Errorf("unsupported transport %q", transport)
+	}
+
+	if token == "" && !isLoopback(addr) {
+		fmt.Fprintf(os.Stderr, "Warning: serving on %s without a bearer token; set -token or %s\n", addr, tokenEnv)
+	}
+	fmt.Fprintf(os.Stderr, "Component Analyzer MCP server listening on http://%s%s\n", addr, endpoint)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- t.Start(addr) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := t.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// requireBearer rejects requests that do not carry token as a bearer token.
+// An empty token disables the check.
+func requireBearer(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="component-analyzer"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopback reports whether addr only listens on the loopback interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func main() {
-	projectRoot := ""
-	if len(os.Args) > 1 {
-		projectRoot = os.Args[1]
+	root := flag.String("root", "", "React project root to analyze (default: current directory)")
+	transport := flag.String("transport", "stdio", "MCP transport: stdio, http (streamable HTTP) or sse")
+	addr := flag.String("addr", defaultAddr, "listen address of the http and sse transports")
+	token := flag.String("token", "", "bearer token required by the http and sse transports (default: $"+tokenEnv+")")
+	flag.Parse()
+	if !slices.Contains([]string{"stdio", "http", "sse"}, *transport) {
+		fmt.Fprintf(os.Stderr, "unsupported transport %q, expected stdio, http or sse\n", *transport)
+		os.Exit(2)
 	}
 
-	s := NewComponentAnalyzer(projectRoot)
+	// The project root used to be the only, positional, argument.
+	projectRoot := *root
+	if projectRoot == "" && flag.NArg() > 0 {
+		projectRoot = flag.Arg(0)
+	}
+	if *token == "" {
+		*token = os.Getenv(tokenEnv)
+	}
 
-	fmt.Fprintln(os.Stderr, "Component Analyzer MCP server running")
+	s := NewComponentAnalyzer(projectRoot)
 
-	if err := server.ServeStdio(s.mcpServer); err != nil {
+	var err error
+	switch *transport {
+	case "stdio":
+		fmt.Fprintln(os.Stderr, "Component Analyzer MCP server running")
+		err = server.ServeStdio(s.mcpServer)
+	default:
+		err = s.serveHTTP(*transport, *addr, *token)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
 		os.Exit(1)
 	}